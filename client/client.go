@@ -1,16 +1,20 @@
 package client
 
 import (
+	"bufio"
 	"context"
-	"errors"
+	"crypto/tls"
 	"net"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/util"
 	"github.com/xtaci/kcp-go/v5"
+	"golang.org/x/net/websocket"
 	"google.golang.org/protobuf/encoding/prototext"
 	pbproto "google.golang.org/protobuf/proto"
 )
@@ -18,60 +22,155 @@ import (
 const (
 	defaultReconnectInterval = 1 * time.Second
 	defaultSendBufferSize    = 100
+	defaultHeartbeatInterval = 10 * time.Second
+	defaultHeartbeatTimeout  = 30 * time.Second
+	defaultWebSocketOrigin   = "http://localhost/"
+	defaultClientVersion     = "dev"
+	defaultMaxBatchSize      = 32
+)
+
+var (
+	// Capabilities supported by the client.
+	supportedCapabilities = []string{
+		proto.CapabilityHeartbeat,
+		proto.CapabilityCompression,
+		proto.CapabilityShutdown,
+		proto.CapabilityBatch,
+	}
 )
 
 type dialer func() (net.Conn, error)
-type dialerFactory func(addr string) dialer
 
-func makeTCPDialer(addr string) dialer {
+func makeTCPDialer(addr string, tlsConf *tls.Config) dialer {
 	return func() (net.Conn, error) {
+		if tlsConf != nil {
+			return tls.Dial("tcp", addr, tlsConf)
+		}
+
 		return net.Dial("tcp", addr)
 	}
 }
 
-func makeUDPDialer(addr string) dialer {
+func makeUDPDialer(addr string, block kcp.BlockCrypt, opt util.KCPOption) dialer {
+	return func() (net.Conn, error) {
+		sess, err := kcp.DialWithOptions(addr, block, opt.DataShards, opt.ParityShards)
+		if err != nil {
+			return nil, err
+		}
+
+		opt.Apply(sess)
+		return sess, nil
+	}
+}
+
+func makeWebSocketDialer(url string, tlsConf *tls.Config) dialer {
 	return func() (net.Conn, error) {
-		return kcp.Dial(addr)
+		// Origin is required by the handshake, but not checked by server.
+		config, err := websocket.NewConfig(url, defaultWebSocketOrigin)
+		if err != nil {
+			return nil, err
+		}
+		config.TlsConfig = tlsConf
+
+		ws, err := websocket.DialConfig(config)
+		if err != nil {
+			return nil, err
+		}
+
+		// Each protobuf message is carried by a single binary WebSocket message.
+		ws.PayloadType = websocket.BinaryFrame
+		return ws, nil
 	}
 }
 
+// codecBox boxes the codec to be stored in atomic value.
+type codecBox struct {
+	proto.MessageCodec
+}
+
 // Client interacts with the game server, including establishing connection
 // to server, reading data from server and writing data to server etc.
 //
-// TODO: Add heartbeating mechanism (eg., ping/pong) so that client can
-// detect connection disruption especially for UDP protocol.
+// Client also sends PING heartbeat periodically so that it can detect connection
+// disruption especially for UDP protocol.
 type Client struct {
 	dialer dialer
-	codec  proto.Codec
+	codec  proto.MessageCodec
 	conn   atomic.Value
 
+	clientVersion      string       // Client build version sent by HELLO
+	platform           string       // Client platform sent by HELLO
+	capabilities       []string     // Capabilities sent by HELLO
+	agreedCapabilities atomic.Value // Capabilities supported by both client and server
+
+	compressions []proto.Compression // Compressions to negotiate in preference order
+	encoder      atomic.Value        // Codec with negotiated compression to encode and decode messages
+
+	tlsConfig  *tls.Config    // TLS config for TCP connection
+	blockCrypt kcp.BlockCrypt // Block crypt for KCP session
+	kcpOption  util.KCPOption // KCP session tuning
+
+	heartbeatInterval atomic.Int64 // Heartbeat interval in nanoseconds
+	heartbeatTimeout  atomic.Int64 // Liveness timeout in nanoseconds
+	lastReceived      atomic.Int64 // Last time received from server in unix nanoseconds
+	reconnectAfter    atomic.Int64 // Delay before reconnecting hinted by server in nanoseconds
+
+	datagramEndpoint string                          // Endpoint of the datagram channel overriding the advertised one
+	datagram         atomic.Pointer[datagramChannel] // Datagram channel bound to the session
+
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	mu          sync.Mutex
 	callbacks   []OnMessageCallback
+	futures     *futureRegistry
 	recovering  atomic.Bool
 	reconnectCh chan struct{}
 	requestCh   chan *proto.Message
 }
 
-func NewTCPClient(addr string) *Client {
-	return newClient(makeTCPDialer(addr))
+func NewTCPClient(addr string, opts ...Option) *Client {
+	c := newClient(opts...)
+	c.dialer = makeTCPDialer(addr, c.tlsConfig)
+	return c
+}
+
+func NewUDPClient(addr string, opts ...Option) *Client {
+	c := newClient(opts...)
+	c.dialer = makeUDPDialer(addr, c.blockCrypt, c.kcpOption)
+	return c
 }
 
-func NewUDPClient(addr string) *Client {
-	return newClient(makeUDPDialer(addr))
+// NewWebSocketClient creates client connecting to the WebSocket url, eg.,
+// "ws://127.0.0.1:8787/ws", or "wss://..." along with `WithTLS` option.
+func NewWebSocketClient(url string, opts ...Option) *Client {
+	c := newClient(opts...)
+	c.dialer = makeWebSocketDialer(url, c.tlsConfig)
+	return c
 }
 
-func newClient(dial dialer) *Client {
+func newClient(opts ...Option) *Client {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Client{
-		dialer:      dial,
-		ctx:         ctx,
-		cancel:      cancel,
-		requestCh:   make(chan *proto.Message, defaultSendBufferSize),
-		reconnectCh: make(chan struct{}),
+	c := &Client{
+		codec:         proto.NewCodec(),
+		clientVersion: defaultClientVersion,
+		platform:      runtime.GOOS + "/" + runtime.GOARCH,
+		capabilities:  supportedCapabilities,
+		kcpOption:     util.KCPPresets[util.KCPPresetNormal],
+		ctx:           ctx,
+		cancel:        cancel,
+		futures:       newFutureRegistry(),
+		requestCh:     make(chan *proto.Message, defaultSendBufferSize),
+		reconnectCh:   make(chan struct{}),
 	}
+
+	c.heartbeatInterval.Store(int64(defaultHeartbeatInterval))
+	c.heartbeatTimeout.Store(int64(defaultHeartbeatTimeout))
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 func (c *Client) Connect() error {
@@ -89,7 +188,12 @@ func (c *Client) Close() {
 		conn.Close()
 	}
 
+	if dc := c.datagram.Swap(nil); dc != nil {
+		dc.conn.Close()
+	}
+
 	c.cancel()
+	c.futures.failAll(errClientClosed)
 }
 
 func (c *Client) handleConnection(conn net.Conn) {
@@ -99,8 +203,27 @@ func (c *Client) handleConnection(conn net.Conn) {
 	ctx, cancel := context.WithCancel(c.ctx)
 	defer cancel()
 
+	c.lastReceived.Store(time.Now().UnixNano())
+
+	// HELLO must be the first message on every connection, so it's written
+	// ahead of any request queued before the connection established.
+	c.agreedCapabilities.Store(map[string]bool{})
+	if err := c.hello(conn); err != nil {
+		logrus.WithFields(logrus.Fields{
+			"serverAddr": conn.RemoteAddr(),
+			"protocol":   conn.RemoteAddr().Network(),
+		}).WithError(err).Debug("Client failed to send HELLO to server")
+	}
+
+	// Compression is negotiated for each connection.
+	c.encoder.Store(codecBox{c.codec})
+	if len(c.compressions) > 0 {
+		c.send(&proto.NegotiateRequest{Compressions: c.compressions})
+	}
+
 	go c.read(ctx, conn)
 	go c.write(ctx, conn)
+	go c.heartbeat(ctx, conn)
 
 	for { // Start failure recover loop
 		select {
@@ -117,6 +240,7 @@ func (c *Client) handleConnection(conn net.Conn) {
 }
 
 func (c *Client) read(ctx context.Context, conn net.Conn) {
+	r := bufio.NewReader(conn)
 	for {
 		select {
 		case <-ctx.Done():
@@ -124,7 +248,7 @@ func (c *Client) read(ctx context.Context, conn net.Conn) {
 		default:
 		}
 
-		msg, err := c.codec.Decode(conn)
+		msg, err := c.encoder.Load().(codecBox).Decode(r)
 		if err == nil {
 			logrus.WithFields(logrus.Fields{
 				"serverAddr": conn.RemoteAddr(),
@@ -132,7 +256,8 @@ func (c *Client) read(ctx context.Context, conn net.Conn) {
 			}).WithField("message", prototext.Format(msg)).
 				Debug("Client read new proto message from server")
 
-			c.notifyOnMessage(msg)
+			c.lastReceived.Store(time.Now().UnixNano())
+			c.dispatch(msg)
 			continue
 		}
 
@@ -146,13 +271,43 @@ func (c *Client) read(ctx context.Context, conn net.Conn) {
 	}
 }
 
+// dispatch handles the message received from server, and the batched messages
+// one by one.
+func (c *Client) dispatch(msg *proto.Message) {
+	if batch := msg.GetBatch(); batch != nil {
+		for _, m := range batch.Messages {
+			c.dispatch(m)
+		}
+		return
+	}
+
+	if pong := msg.GetResponse().GetPong(); pong != nil {
+		c.onPong(pong)
+	}
+
+	if hello := msg.GetResponse().GetHello(); hello != nil {
+		c.onHello(hello)
+	}
+
+	if negotiated := msg.GetResponse().GetNegotiate(); negotiated != nil {
+		c.onNegotiate(negotiated)
+	}
+
+	if shutdown := msg.GetEvent().GetShutdown(); shutdown != nil {
+		c.onShutdown(shutdown)
+	}
+
+	c.futures.resolve(msg)
+	c.notifyOnMessage(msg)
+}
+
 func (c *Client) write(ctx context.Context, conn net.Conn) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case msg := <-c.requestCh:
-			err := c.codec.Encode(msg, conn)
+			err := c.encoder.Load().(codecBox).Encode(c.coalesce(msg), conn)
 			if err != nil {
 				logrus.WithFields(logrus.Fields{
 					"serverAddr": conn.RemoteAddr(),
@@ -166,9 +321,124 @@ func (c *Client) write(ctx context.Context, conn net.Conn) {
 	}
 }
 
+// coalesce batches the queued requests along with the message in one frame,
+// if batch capability agreed by server.
+func (c *Client) coalesce(msg *proto.Message) *proto.Message {
+	if len(c.requestCh) == 0 || !c.HasCapability(proto.CapabilityBatch) {
+		return msg
+	}
+
+	msgs := []*proto.Message{msg}
+	for len(msgs) < defaultMaxBatchSize {
+		select {
+		case m := <-c.requestCh:
+			msgs = append(msgs, m)
+		default:
+			return proto.NewBatchMessage(msgs...)
+		}
+	}
+
+	return proto.NewBatchMessage(msgs...)
+}
+
+// heartbeat sends PING periodically, and recovers the connection if nothing
+// received from server within the liveness timeout.
+func (c *Client) heartbeat(ctx context.Context, conn net.Conn) {
+	timer := time.NewTimer(time.Duration(c.heartbeatInterval.Load()))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		lastReceived := time.Unix(0, c.lastReceived.Load())
+		if time.Since(lastReceived) >= time.Duration(c.heartbeatTimeout.Load()) {
+			logrus.WithFields(logrus.Fields{
+				"serverAddr":   conn.RemoteAddr(),
+				"protocol":     conn.RemoteAddr().Network(),
+				"lastReceived": lastReceived,
+			}).Debug("Client heartbeat timeout")
+
+			c.failureRecover()
+			return
+		}
+
+		c.Ping()
+		timer.Reset(time.Duration(c.heartbeatInterval.Load()))
+	}
+}
+
+// hello writes the HELLO handshake to the connection directly.
+func (c *Client) hello(conn net.Conn) error {
+	msg, err := proto.NewRequestMessage(&proto.HelloRequest{
+		ProtocolVersion: proto.ProtocolVersion,
+		ClientVersion:   c.clientVersion,
+		Platform:        c.platform,
+		Capabilities:    c.capabilities,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.codec.Encode(msg, conn)
+}
+
+// onHello records the capabilities supported by both client and server.
+func (c *Client) onHello(resp *proto.HelloResponse) {
+	agreed := make(map[string]bool, len(resp.Capabilities))
+	for _, v := range resp.Capabilities {
+		agreed[v] = true
+	}
+
+	c.agreedCapabilities.Store(agreed)
+}
+
+// HasCapability checks if the capability is agreed by server in HELLO handshake.
+func (c *Client) HasCapability(capability string) bool {
+	agreed, _ := c.agreedCapabilities.Load().(map[string]bool)
+	return agreed[capability]
+}
+
+// onPong adopts the liveness policy agreed by server.
+func (c *Client) onPong(pong *proto.PongResponse) {
+	if pong.IntervalMs > 0 {
+		c.heartbeatInterval.Store(int64(time.Duration(pong.IntervalMs) * time.Millisecond))
+	}
+
+	if pong.TimeoutMs > 0 {
+		c.heartbeatTimeout.Store(int64(time.Duration(pong.TimeoutMs) * time.Millisecond))
+	}
+}
+
+// onNegotiate adopts the compression agreed by server.
+func (c *Client) onNegotiate(resp *proto.NegotiateResponse) {
+	codec, ok := c.codec.(*proto.Codec)
+	if !ok || resp.Compression == proto.Compression_NONE {
+		return
+	}
+
+	c.encoder.Store(codecBox{codec.WithCompression(resp.Compression, int(resp.Threshold))})
+}
+
+// onShutdown adopts the reconnect hint once server starts draining.
+func (c *Client) onShutdown(event *proto.ShutdownEvent) {
+	if event.ReconnectAfterMs > 0 {
+		c.reconnectAfter.Store(int64(time.Duration(event.ReconnectAfterMs) * time.Millisecond))
+	}
+}
+
 func (c *Client) reconnect(ctx context.Context) (conn net.Conn, err error) {
+	delay := defaultReconnectInterval
+	if v := c.reconnectAfter.Swap(0); v > 0 {
+		// Wait as server hinted, so that the restarted server is ready.
+		delay = time.Duration(v)
+	}
+
 	// TODO: Use exponetial backoff for retry mechanism.
-	timer := time.NewTimer(defaultReconnectInterval)
+	timer := time.NewTimer(delay)
 	defer timer.Stop()
 
 	for {
@@ -197,26 +467,64 @@ func (c *Client) failureRecover() {
 		conn.Close()
 	}
 
+	// Responses of the pending requests are lost along with the connection.
+	c.futures.failAll(errConnectionLost)
+
 	// Try to reconnect the server
 	if len(c.reconnectCh) == 0 {
 		c.reconnectCh <- struct{}{}
 	}
 }
 
+// send sends the request without waiting for the response, which is only
+// dispatched to the callbacks.
 func (c *Client) send(m pbproto.Message) error {
 	msg, err := proto.NewRequestMessage(m)
 	if err != nil {
 		return err
 	}
 
+	msg.Seq = c.futures.next()
+	return c.enqueue(msg)
+}
+
+// Go sends the request asynchronously, and returns a future to wait for the response.
+func (c *Client) Go(m pbproto.Message) (*Future, error) {
+	msg, err := proto.NewRequestMessage(m)
+	if err != nil {
+		return nil, err
+	}
+
+	f := c.futures.register()
+	msg.Seq = f.Seq
+
+	if err := c.enqueue(msg); err != nil {
+		c.futures.remove(f.Seq)
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (c *Client) enqueue(msg *proto.Message) error {
 	select {
 	case c.requestCh <- msg:
 		return nil
 	default:
-		return errors.New("send buffer is full")
+		return errSendBufferFull
 	}
 }
 
+// Call sends the request and waits for the response.
+func (c *Client) Call(ctx context.Context, m pbproto.Message) (*proto.Message, error) {
+	f, err := c.Go(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Wait(ctx)
+}
+
 type OnMessageCallback func(msg *proto.Message)
 
 func (c *Client) notifyOnMessage(msg *proto.Message) {
@@ -250,6 +558,10 @@ func (c *Client) Logout() error {
 	return c.send(&proto.LogoutRequest{})
 }
 
+func (c *Client) Ping() error {
+	return c.send(&proto.PingRequest{Timestamp: time.Now().UnixMilli()})
+}
+
 func (c *Client) GenerateRandomNickname(sex, culture int32) error {
 	return c.send(&proto.GenerateRandomNicknameRequest{
 		Sex: sex, Culture: culture,
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wanliqun/cgo-game-server/proto"
+	pbproto "google.golang.org/protobuf/proto"
+)
+
+const (
+	datagramReadBuffSize = 64 * 1024 // Max UDP payload
+)
+
+var (
+	errDatagramNotBound = errors.New("datagram channel not bound")
+)
+
+// datagramChannel is the unreliable datagram channel bound to the session.
+type datagramChannel struct {
+	conn    *net.UDPConn
+	token   []byte
+	key     []byte     // Secret key to authenticate the datagrams
+	sendSeq uint64     // Sequence number of the last sent datagram
+	recvSeq uint64     // Sequence number of the last received datagram, only used by the reader
+	mu      sync.Mutex // Keeps the sequence numbers in the sending order
+}
+
+func (dc *datagramChannel) send(msg *proto.Message) error {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	var data []byte
+	if msg != nil {
+		var err error
+		if data, err = pbproto.Marshal(msg); err != nil {
+			return err
+		}
+	}
+
+	dc.sendSeq++
+	dg := &proto.Datagram{Token: dc.token, Seq: dc.sendSeq, Message: data}
+	dg.Sign(dc.key, false)
+
+	b, err := pbproto.Marshal(dg)
+	if err != nil {
+		return err
+	}
+
+	_, err = dc.conn.Write(b)
+	return err
+}
+
+// BindDatagram binds the unreliable datagram channel to the session, which must
+// be logged in. Messages received over the channel are dispatched the same as
+// the ones over the connection, except that stale or unauthenticated ones are
+// dropped. The channel should be bound again once reconnected.
+func (c *Client) BindDatagram(ctx context.Context) error {
+	resp, err := c.Call(ctx, &proto.DatagramBindRequest{})
+	if err != nil {
+		return err
+	}
+
+	bound := resp.GetResponse().GetDatagramBind()
+	if bound == nil {
+		return errors.New(resp.GetResponse().GetStatus().GetMessage())
+	}
+
+	endpoint, err := c.resolveDatagramEndpoint(bound.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	raddr, err := net.ResolveUDPAddr("udp", endpoint)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialUDP("udp", nil, raddr)
+	if err != nil {
+		return err
+	}
+
+	dc := &datagramChannel{conn: conn, token: bound.Token, key: bound.Key}
+	if old := c.datagram.Swap(dc); old != nil {
+		old.conn.Close()
+	}
+
+	go c.readDatagrams(dc)
+
+	// Probe so that server learns the client address, otherwise learned from
+	// the next datagram if lost.
+	return dc.send(nil)
+}
+
+// SendDatagram sends the request over the bound datagram channel without any
+// delivery guarantee, whose response is dispatched to the `OnMessage` callbacks
+// if not lost.
+func (c *Client) SendDatagram(m pbproto.Message) error {
+	dc := c.datagram.Load()
+	if dc == nil {
+		return errDatagramNotBound
+	}
+
+	msg, err := proto.NewRequestMessage(m)
+	if err != nil {
+		return err
+	}
+
+	return dc.send(msg)
+}
+
+// resolveDatagramEndpoint resolves the endpoint advertised by server, whose host
+// defaults to the one of the connection if empty or unspecified.
+func (c *Client) resolveDatagramEndpoint(endpoint string) (string, error) {
+	if len(c.datagramEndpoint) > 0 {
+		return c.datagramEndpoint, nil
+	}
+
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	if ip := net.ParseIP(host); len(host) > 0 && (ip == nil || !ip.IsUnspecified()) {
+		return endpoint, nil
+	}
+
+	conn, ok := c.conn.Load().(net.Conn)
+	if !ok {
+		return "", errConnectionLost
+	}
+
+	host, _, err = net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return "", errors.New("unknown datagram host, specify by `WithDatagramEndpoint`")
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+func (c *Client) readDatagrams(dc *datagramChannel) {
+	buf := make([]byte, datagramReadBuffSize)
+	for {
+		n, err := dc.conn.Read(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+
+		if err != nil {
+			// Eg., ICMP port unreachable if server not yet listening.
+			logrus.WithField("serverAddr", dc.conn.RemoteAddr()).
+				WithError(err).
+				Debug("Client failed to read datagram from server")
+			continue
+		}
+
+		var dg proto.Datagram
+		if err := pbproto.Unmarshal(buf[:n], &dg); err != nil || !dg.Verify(dc.key, true) {
+			continue
+		}
+
+		if dg.Seq <= dc.recvSeq {
+			// Stale datagram, which is superseded by the newer one.
+			continue
+		}
+
+		msg := new(proto.Message)
+		if err := pbproto.Unmarshal(dg.Message, msg); err != nil || len(dg.Message) == 0 {
+			continue
+		}
+
+		dc.recvSeq = dg.Seq
+		c.dispatch(msg)
+	}
+}
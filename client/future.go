@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+var (
+	errConnectionLost = errors.New("connection lost")
+	errClientClosed   = errors.New("client closed")
+	errSendBufferFull = errors.New("send buffer is full")
+)
+
+// Future represents the pending response of a request identified by the sequence ID.
+type Future struct {
+	Seq      uint64
+	registry *futureRegistry
+	done     chan struct{}
+	resp     *proto.Message
+	err      error
+}
+
+func newFuture(r *futureRegistry, seq uint64) *Future {
+	return &Future{Seq: seq, registry: r, done: make(chan struct{})}
+}
+
+// Done returns a channel that's closed when the response arrives or the request fails.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the response arrives, the request fails or the context is done,
+// in which case the future is deregistered and the late response is ignored.
+func (f *Future) Wait(ctx context.Context) (*proto.Message, error) {
+	select {
+	case <-ctx.Done():
+		f.registry.remove(f.Seq)
+		return nil, ctx.Err()
+	case <-f.done:
+		return f.resp, f.err
+	}
+}
+
+func (f *Future) resolve(resp *proto.Message, err error) {
+	f.resp, f.err = resp, err
+	close(f.done)
+}
+
+// futureRegistry keeps track of pending futures by sequence ID.
+type futureRegistry struct {
+	mu      sync.Mutex
+	seq     uint64
+	pending map[uint64]*Future
+}
+
+func newFutureRegistry() *futureRegistry {
+	return &futureRegistry{pending: make(map[uint64]*Future)}
+}
+
+// register allocates a new sequence ID and registers a pending future for it.
+func (r *futureRegistry) register() *Future {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	f := newFuture(r, r.seq)
+	r.pending[f.Seq] = f
+
+	return f
+}
+
+// next allocates a new sequence ID without registering any future, eg., for the
+// request whose response is only dispatched to the callbacks.
+func (r *futureRegistry) next() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	return r.seq
+}
+
+func (r *futureRegistry) remove(seq uint64) *Future {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.pending[seq]
+	if ok {
+		delete(r.pending, seq)
+	}
+
+	return f
+}
+
+// resolve resolves the pending future with the response of the same sequence ID.
+func (r *futureRegistry) resolve(resp *proto.Message) bool {
+	if resp.Seq == 0 {
+		// Unsolicited server message
+		return false
+	}
+
+	if f := r.remove(resp.Seq); f != nil {
+		f.resolve(resp, nil)
+		return true
+	}
+
+	return false
+}
+
+// failAll fails all pending futures with the error.
+func (r *futureRegistry) failAll(err error) {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[uint64]*Future)
+	r.mu.Unlock()
+
+	for _, f := range pending {
+		f.resolve(nil, err)
+	}
+}
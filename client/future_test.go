@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+func pendingFutures(r *futureRegistry) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.pending)
+}
+
+func TestFutureRegistry(t *testing.T) {
+	r := newFutureRegistry()
+
+	f1, f2 := r.register(), r.register()
+	assert.NotEqual(t, f1.Seq, f2.Seq)
+
+	// Response is correlated by the sequence ID.
+	assert.True(t, r.resolve(&proto.Message{Seq: f2.Seq}))
+	resp, err := f2.Wait(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, f2.Seq, resp.Seq)
+
+	// Unsolicited or unknown message resolves nothing.
+	assert.False(t, r.resolve(&proto.Message{}))
+	assert.False(t, r.resolve(&proto.Message{Seq: f2.Seq}))
+
+	r.failAll(errConnectionLost)
+	_, err = f1.Wait(context.Background())
+	assert.ErrorIs(t, err, errConnectionLost)
+	assert.Zero(t, pendingFutures(r))
+}
+
+func TestFutureWaitCanceled(t *testing.T) {
+	r := newFutureRegistry()
+	f := r.register()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Future is deregistered once given up, and the late response ignored.
+	_, err := f.Wait(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, pendingFutures(r))
+	assert.False(t, r.resolve(&proto.Message{Seq: f.Seq}))
+}
+
+func TestClientSendWithoutFuture(t *testing.T) {
+	c := NewTCPClient("127.0.0.1:0")
+	defer c.Close()
+
+	// Fire-and-forget requests, eg., heartbeats, register no future.
+	require.NoError(t, c.Ping())
+	require.NoError(t, c.Info())
+	assert.Zero(t, pendingFutures(c.futures))
+
+	ping, info := <-c.requestCh, <-c.requestCh
+	assert.NotZero(t, ping.Seq)
+	assert.NotEqual(t, ping.Seq, info.Seq)
+}
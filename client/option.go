@@ -0,0 +1,86 @@
+package client
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/util"
+	"github.com/xtaci/kcp-go/v5"
+)
+
+// Option configures the client.
+type Option func(*Client)
+
+// WithHeartbeat sets the heartbeat interval to send PING, and the timeout to
+// consider the connection dead if nothing received from server. They will be
+// overridden by the liveness policy agreed by server once PONG received.
+func WithHeartbeat(interval, timeout time.Duration) Option {
+	return func(c *Client) {
+		c.heartbeatInterval.Store(int64(interval))
+		c.heartbeatTimeout.Store(int64(timeout))
+	}
+}
+
+// WithTLS enables TLS for the TCP or WebSocket client.
+func WithTLS(conf *tls.Config) Option {
+	return func(c *Client) {
+		c.tlsConfig = conf
+	}
+}
+
+// WithBlockCrypt encrypts the KCP session of the UDP client, which must match
+// the one of server, eg., `util.NewKCPBlockCrypt` with the same key and salt.
+func WithBlockCrypt(block kcp.BlockCrypt) Option {
+	return func(c *Client) {
+		c.blockCrypt = block
+	}
+}
+
+// WithKCPOption tunes the KCP session of the UDP client, which must be consistent
+// with the one of server, especially the FEC shards.
+func WithKCPOption(opt util.KCPOption) Option {
+	return func(c *Client) {
+		c.kcpOption = opt
+	}
+}
+
+// WithCodec sets the wire format codec, which must be consistent with the one
+// of server listener. Defaults to 4-byte big endian length-prefixed protobuf.
+func WithCodec(codec proto.MessageCodec) Option {
+	return func(c *Client) {
+		c.codec = codec
+	}
+}
+
+// WithCompression negotiates per-frame compression in preference order with
+// server once connected, only for the length-prefixed protobuf codec.
+func WithCompression(compressions ...proto.Compression) Option {
+	return func(c *Client) {
+		c.compressions = compressions
+	}
+}
+
+// WithClientInfo sets the client build version and platform sent by HELLO
+// handshake. Defaults to "dev" and the GOOS/GOARCH of the client.
+func WithClientInfo(version, platform string) Option {
+	return func(c *Client) {
+		c.clientVersion, c.platform = version, platform
+	}
+}
+
+// WithCapabilities sets the feature capabilities sent by HELLO handshake.
+// Defaults to all the capabilities supported by the client.
+func WithCapabilities(capabilities ...string) Option {
+	return func(c *Client) {
+		c.capabilities = capabilities
+	}
+}
+
+// WithDatagramEndpoint overrides the endpoint of the datagram channel advertised
+// by server, eg., behind NAT or a UDP relay.
+func WithDatagramEndpoint(endpoint string) Option {
+	return func(c *Client) {
+		c.datagramEndpoint = endpoint
+	}
+}
@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"log"
 	"math/rand"
+	"os"
 	"time"
 
 	"github.com/manifoldco/promptui"
@@ -12,12 +15,19 @@ import (
 	"github.com/wanliqun/cgo-game-server/common"
 	"github.com/wanliqun/cgo-game-server/config"
 	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/util"
 )
 
 type simulatorOption struct {
-	srvAddr  string
-	userName string
-	password string
+	srvAddr   string
+	userName  string
+	password  string
+	useTLS    bool
+	tlsCA     string
+	kcpKey    string
+	kcpSalt   string
+	kcpPreset string
+	codec     string
 }
 
 var (
@@ -53,6 +63,36 @@ func init() {
 		"The password used to login in the server",
 	)
 
+	simulatorCmd.Flags().BoolVar(
+		&simOpts.useTLS, "tls", false,
+		"Use TLS for the TCP client",
+	)
+
+	simulatorCmd.Flags().StringVar(
+		&simOpts.tlsCA, "tls-ca", "",
+		"The CA file to verify server certificate, empty to use system roots",
+	)
+
+	simulatorCmd.Flags().StringVar(
+		&simOpts.kcpKey, "kcp-key", "",
+		"The pre-shared key to encrypt the UDP client, empty means no encryption",
+	)
+
+	simulatorCmd.Flags().StringVar(
+		&simOpts.kcpSalt, "kcp-salt", "cgo-game-server",
+		"The salt to derive the UDP client encryption key",
+	)
+
+	simulatorCmd.Flags().StringVar(
+		&simOpts.kcpPreset, "kcp-preset", util.KCPPresetNormal,
+		"The KCP preset of the UDP client, available presets are `fast`, `normal` and `bandwidth-saver`",
+	)
+
+	simulatorCmd.Flags().StringVar(
+		&simOpts.codec, "codec", proto.CodecProtobuf,
+		"The wire format codec, available codecs are `protobuf`, `varint-protobuf`, `json` and `jsonline`",
+	)
+
 	simulatorCmd.Flags().BoolVarP(
 		&verbose,
 		"verbose", "v", false,
@@ -74,6 +114,11 @@ func runSimulator(*cobra.Command, []string) {
 	defer gc.Close()
 
 	gc.OnMessage(func(msg *proto.Message) {
+		if msg.Type == proto.MessageType_PONG {
+			// Skip heartbeat
+			return
+		}
+
 		log.Println(">>> New message received from server:", msg.String())
 	})
 
@@ -135,10 +180,37 @@ func chooseGameClient(srvAddr string) (c *client.Client, err error) {
 	}
 
 	log.Printf("You've chosen a %s client\n", clientType)
+
+	codec, err := proto.NewCodecByName(simOpts.codec)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []client.Option{client.WithCodec(codec)}
 	if idx == 0 {
-		c = client.NewTCPClient(srvAddr)
+		if simOpts.useTLS {
+			tlsConf, err := newClientTLSConfig(simOpts.tlsCA)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, client.WithTLS(tlsConf))
+		}
+		c = client.NewTCPClient(srvAddr, opts...)
 	} else {
-		c = client.NewUDPClient(srvAddr)
+		kcpOption, err := util.NewKCPOptionFromPreset(simOpts.kcpPreset)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, client.WithKCPOption(kcpOption))
+		if len(simOpts.kcpKey) > 0 {
+			block, err := util.NewKCPBlockCrypt(simOpts.kcpKey, simOpts.kcpSalt)
+			if err != nil {
+				return nil, errors.WithMessage(err, "failed to new KCP block crypt")
+			}
+			opts = append(opts, client.WithBlockCrypt(block))
+		}
+		c = client.NewUDPClient(srvAddr, opts...)
 	}
 
 	if err := c.Connect(); err != nil {
@@ -147,3 +219,22 @@ func chooseGameClient(srvAddr string) (c *client.Client, err error) {
 
 	return c, nil
 }
+
+func newClientTLSConfig(caFile string) (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+	if len(caFile) == 0 {
+		return conf, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read CA file")
+	}
+
+	conf.RootCAs = x509.NewCertPool()
+	if !conf.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no valid CA certificate found")
+	}
+
+	return conf, nil
+}
@@ -14,6 +14,7 @@ var (
 	_ Command = (*LogoutCommand)(nil)
 	_ Command = (*InfoCommand)(nil)
 	_ Command = (*GenerateRandomNicknameCommand)(nil)
+	_ Command = (*DatagramBindCommand)(nil)
 )
 
 type Command interface {
@@ -35,9 +36,13 @@ func NewLoginCommand(
 
 func (cmd *LoginCommand) Execute(ctx context.Context) (pbproto.Message, error) {
 	session := ctx.Value(server.CtxKeySession).(*server.Session)
-	_, err := cmd.playerService.Login(cmd.reqeuest, session)
+	_, queueStatus, err := cmd.playerService.Login(cmd.reqeuest, session)
+	if err != nil || queueStatus == nil {
+		return nil, err
+	}
 
-	return nil, err
+	// Server is full, wait in the login queue.
+	return queueStatus, nil
 }
 
 type LogoutCommand struct {
@@ -94,3 +99,19 @@ func (cmd *GenerateRandomNicknameCommand) Execute(ctx context.Context) (pbproto.
 	nickname := cmd.axService.Generate(cmd.request.Sex, cmd.request.Culture)
 	return &proto.GenerateRandomNicknameResponse{Nickname: nickname}, nil
 }
+
+type DatagramBindCommand struct{}
+
+func NewDatagramBindCommand() *DatagramBindCommand {
+	return &DatagramBindCommand{}
+}
+
+func (cmd *DatagramBindCommand) Execute(ctx context.Context) (pbproto.Message, error) {
+	session := ctx.Value(server.CtxKeySession).(*server.Session)
+	token, key, endpoint, err := session.BindDatagram()
+	if err != nil {
+		return nil, server.NewBadRequestError(err)
+	}
+
+	return &proto.DatagramBindResponse{Token: token, Endpoint: endpoint, Key: key}, nil
+}
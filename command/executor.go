@@ -35,6 +35,8 @@ func (e *Executor) Execute(ctx context.Context, msg *server.Message) *server.Mes
 	case req.GetGenerateRandomNickname() != nil:
 		v := req.GetGenerateRandomNickname()
 		cmd = NewGenerateRandomNicknameCommand(v, e.svcFactory.Auxiliary)
+	case req.GetDatagramBind() != nil:
+		cmd = NewDatagramBindCommand()
 	// TODO: extend for more message types support
 	default:
 		err := server.NewBadRequestError(errMsgTypeNotSupported)
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env"
@@ -26,14 +27,149 @@ type LogConfig struct {
 	ForceColor bool   `default:"true"`
 }
 
+type PipelineConfig struct {
+	Enabled             bool     `default:"false"`
+	MaxInFlight         int      `default:"16"`
+	OrderedMessageTypes []string `default:"[LOGIN,LOGOUT]"`
+}
+
+type HeartbeatConfig struct {
+	Interval time.Duration `default:"10s"`
+	Timeout  time.Duration `default:"30s"`
+}
+
+type FrameConfig struct {
+	MaxSize             int           `default:"1048576"` // 0 means only limited by the codec (16MB)
+	ReadTimeout         time.Duration `default:"10s"`     // 0 means unlimited
+	HeaderToBodyTimeout time.Duration `default:"5s"`      // 0 means unlimited
+}
+
+type BatchConfig struct {
+	MaxSize int `default:"64"` // Max number of messages per batch
+}
+
+type ProxyProtocolConfig struct {
+	Enabled       bool
+	TrustedCIDRs  []string      // Sources allowed to send the header, eg., load balancers
+	HeaderTimeout time.Duration `default:"5s"`
+}
+
+type IPLimitConfig struct {
+	MaxConns    int      // Max concurrent connections per IP, 0 means unlimited
+	AcceptRate  float64  // Accepted connections per second per IP, 0 means unlimited
+	AcceptBurst int      `default:"10"`
+	ExemptCIDRs []string // Exempted sources, eg., test rigs
+}
+
+type ReactorConfig struct {
+	Enabled bool
+	Workers int // 0 means 8 per CPU
+}
+
+type OutboundConfig struct {
+	QueueSize          int           `default:"256"`
+	WriteTimeout       time.Duration `default:"10s"` // 0 means unlimited
+	SlowConsumerPolicy string        `default:"disconnect"`
+}
+
+type DrainConfig struct {
+	Timeout           time.Duration `default:"10s"`
+	ReconnectAfter    time.Duration `default:"5s"`
+	ReconnectEndpoint string        // Empty means reconnecting to the same endpoint
+}
+
+type HotRestartConfig struct {
+	Enabled      bool          // Whether to hot restart on SIGUSR2, which is opt-in
+	ReadyTimeout time.Duration `default:"30s"` // Max duration to wait for the new process to be ready
+}
+
+type TLSConfig struct {
+	Enabled        bool
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string        // Verify client certificates if specified
+	ReloadInterval time.Duration `default:"10s"`
+}
+
+type KCPCryptConfig struct {
+	Key  string // Pre-shared key, empty means no encryption
+	Salt string `default:"cgo-game-server"`
+}
+
+// KCPConfig tunes KCP sessions with the named preset, whose settings can be
+// overridden individually.
+type KCPConfig struct {
+	Preset       string `default:"normal"` // Available presets are `fast`, `normal` and `bandwidth-saver`
+	NoDelay      *bool
+	Interval     *int
+	Resend       *int
+	NoCongestion *bool
+	SndWnd       *int
+	RcvWnd       *int
+	MTU          *int
+	DataShards   *int
+	ParityShards *int
+}
+
+type WebSocketConfig struct {
+	Enabled  bool
+	Endpoint string // Empty means mounted onto the RESTful server
+	Path     string `default:"/ws"`
+	Codec    string `default:"protobuf"`
+}
+
+type TextConfig struct {
+	Enabled  bool
+	Endpoint string `default:"127.0.0.1:8766"`
+}
+
+type DatagramConfig struct {
+	Enabled            bool
+	Endpoint           string `default:":8768"`
+	AdvertisedEndpoint string // Empty means the same as endpoint
+}
+
+type CompressionConfig struct {
+	Algorithms []string `default:"[ZSTD,SNAPPY]"` // Empty means no compression
+	Threshold  int      `default:"1024"`
+}
+
+type HandshakeConfig struct {
+	Required           bool   `default:"true"` // Whether HELLO must be the first message on every connection
+	MinProtocolVersion uint32 `default:"1"`    // Min protocol version supported
+}
+
 type ServerConfig struct {
-	Name                  string `default:"cgo_game_server"`
-	Password              string `default:"helloworld"`
-	TCPEndpoint           string `default:":8765"`
-	UDPEndpoint           string `default:":8765"`
-	HTTPEndpoint          string `default:":8787"`
-	MaxPlayerCapacity     int    `default:"10000"`
-	MaxConnectionCapacity int    `default:"15000"`
+	Name                     string `default:"cgo_game_server"`
+	Password                 string `default:"helloworld"`
+	TCPEndpoint              string `default:":8765"`
+	UDPEndpoint              string `default:":8765"`
+	HTTPEndpoint             string `default:":8787"`
+	TCPCodec                 string `default:"protobuf"` // Wire format of TCP listener
+	UDPCodec                 string `default:"protobuf"` // Wire format of UDP listener
+	MaxPlayerCapacity        int    `default:"10000"`
+	MaxLoginQueueSize        int    `default:"10000"` // 0 means unlimited
+	MaxConnectionCapacity    int    `default:"15000"`
+	MaxTCPConnectionCapacity int    `default:"0"` // 0 means only limited by `MaxConnectionCapacity`
+	MaxUDPConnectionCapacity int    `default:"0"` // 0 means only limited by `MaxConnectionCapacity`
+	Pipeline                 PipelineConfig
+	Heartbeat                HeartbeatConfig
+	Frame                    FrameConfig
+	Batch                    BatchConfig
+	Outbound                 OutboundConfig
+	Drain                    DrainConfig
+	HotRestart               HotRestartConfig
+	TLS                      TLSConfig
+	ProxyProtocol            ProxyProtocolConfig
+	IPLimit                  IPLimitConfig
+	Reactor                  ReactorConfig
+	KCPCrypt                 KCPCryptConfig
+	KCP                      KCPConfig
+	WebSocket                WebSocketConfig
+	Text                     TextConfig
+	Datagram                 DatagramConfig
+	Compression              CompressionConfig
+	Handshake                HandshakeConfig
 }
 
 type CGOConfig struct {
@@ -1,9 +1,13 @@
 package game
 
 import (
+	"crypto/tls"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/wanliqun/cgo-game-server/cgo"
 	"github.com/wanliqun/cgo-game-server/command"
 	"github.com/wanliqun/cgo-game-server/common"
@@ -14,14 +18,26 @@ import (
 	"github.com/wanliqun/cgo-game-server/server"
 	"github.com/wanliqun/cgo-game-server/service"
 	"github.com/wanliqun/cgo-game-server/util"
+	"github.com/xtaci/kcp-go/v5"
+)
+
+const (
+	drainReasonShutdown = "server shutting down"
+	drainReasonRestart  = "server restarting"
 )
 
 type Application struct {
 	conf       *config.Config
 	sessionMgr *server.SessionManager
+	drainer    *server.Drainer
 	udpServer  *server.Server
 	tcpServer  *server.Server
+	wsServer   *server.Server // nil if WebSocket disabled
+	textServer *server.Server // nil if text protocol disabled
 	restServer *rest.Server
+	reactor    *server.Reactor     // nil if reactor disabled
+	datagram   *server.DatagramHub // nil if datagram channel disabled
+	handoff    *util.Handoff       // New process taken over on hot restart
 }
 
 func NewApplication() (*Application, error) {
@@ -42,9 +58,21 @@ func NewApplication() (*Application, error) {
 		monickerGenerator = &common.GoFakerNameGenerator{}
 	}
 
-	sessionMgr := server.NewSessionManager()
+	sessionMgr := server.NewSessionManager(cfg.Server.Heartbeat.Timeout)
+	admission := server.NewAdmissionController(
+		cfg.Server.MaxConnectionCapacity, map[string]int{
+			"tcp": cfg.Server.MaxTCPConnectionCapacity,
+			"udp": cfg.Server.MaxUDPConnectionCapacity,
+		},
+	)
+
+	drainer := server.NewDrainer(server.DrainOption{
+		Timeout:           cfg.Server.Drain.Timeout,
+		ReconnectAfter:    cfg.Server.Drain.ReconnectAfter,
+		ReconnectEndpoint: cfg.Server.Drain.ReconnectEndpoint,
+	})
 
-	svcFactory := service.NewFactory(cfg, sessionMgr, monickerGenerator)
+	svcFactory := service.NewFactory(cfg, sessionMgr, admission, drainer, monickerGenerator)
 	cmdExecutor := command.NewExecutor(svcFactory)
 
 	msgHandler, err := middlewares.MiddlewareChain(
@@ -59,30 +87,175 @@ func NewApplication() (*Application, error) {
 		return nil, errors.WithMessage(err, "failed to build middleware chain")
 	}
 
-	codec := proto.NewCodec()
-	connHandler := server.NewConnectionHandler(msgHandler, sessionMgr, codec)
+	connHandler := server.NewConnectionHandler(
+		msgHandler, sessionMgr, admission, drainer, proto.NewCodec(),
+	)
+	connHandler.Pipeline, err = newPipelineOption(&cfg.Server.Pipeline)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to new pipeline option")
+	}
+	connHandler.Heartbeat = server.HeartbeatOption{
+		Interval: cfg.Server.Heartbeat.Interval,
+		Timeout:  cfg.Server.Heartbeat.Timeout,
+	}
+	connHandler.Frame = server.FrameOption{
+		MaxSize:             cfg.Server.Frame.MaxSize,
+		ReadTimeout:         cfg.Server.Frame.ReadTimeout,
+		HeaderToBodyTimeout: cfg.Server.Frame.HeaderToBodyTimeout,
+	}
+	connHandler.IPLimiter, err = newIPLimiter(&cfg.Server.IPLimit)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to new IP limiter")
+	}
+	connHandler.Batch = server.BatchOption{MaxSize: cfg.Server.Batch.MaxSize}
+	connHandler.Outbound, err = newOutboundOption(&cfg.Server.Outbound)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to new outbound option")
+	}
+	connHandler.Compression, err = newCompressionOption(&cfg.Server.Compression)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to new compression option")
+	}
+	connHandler.Handshake = newHandshakeOption(&cfg.Server.Handshake, connHandler.Compression)
 
-	udpServer, err := server.NewUDPServer(cfg.Server.UDPEndpoint, connHandler)
+	var datagram *server.DatagramHub
+	if dgCfg := cfg.Server.Datagram; dgCfg.Enabled {
+		datagram, err = server.NewDatagramHub(dgCfg.Endpoint, dgCfg.AdvertisedEndpoint)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to new datagram hub")
+		}
+
+		// Sessions of all listeners are bound to the same datagram channel.
+		connHandler.Datagram = datagram
+	}
+
+	var block kcp.BlockCrypt
+	if len(cfg.Server.KCPCrypt.Key) > 0 {
+		block, err = util.NewKCPBlockCrypt(cfg.Server.KCPCrypt.Key, cfg.Server.KCPCrypt.Salt)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to new KCP block crypt")
+		}
+	}
+
+	kcpOption, err := newKCPOption(&cfg.Server.KCP)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to new KCP option")
+	}
+
+	udpHandler, err := newCodecConnectionHandler(connHandler, cfg.Server.UDPCodec)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to new UDP codec")
+	}
+
+	udpServer, err := server.NewUDPServer(cfg.Server.UDPEndpoint, udpHandler, block, kcpOption)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to new UDP server")
 	}
 
-	tcpServer, err := server.NewTCPServer(cfg.Server.TCPEndpoint, connHandler)
+	var tlsConf *tls.Config
+	if cfg.Server.TLS.Enabled {
+		tlsConf, err = server.NewTLSConfig(server.TLSOption{
+			CertFile:       cfg.Server.TLS.CertFile,
+			KeyFile:        cfg.Server.TLS.KeyFile,
+			ClientCAFile:   cfg.Server.TLS.ClientCAFile,
+			ReloadInterval: cfg.Server.TLS.ReloadInterval,
+		})
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to new TLS config")
+		}
+	}
+
+	tcpHandler, err := newCodecConnectionHandler(connHandler, cfg.Server.TCPCodec)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to new TCP codec")
+	}
+
+	var proxy *server.ProxyProtocolOption
+	if cfg.Server.ProxyProtocol.Enabled {
+		cidrs, err := util.ParseCIDRs(cfg.Server.ProxyProtocol.TrustedCIDRs)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to parse PROXY protocol trusted CIDRs")
+		}
+
+		if len(cidrs) == 0 {
+			logrus.Warn("PROXY protocol enabled without any trusted source")
+		}
+
+		proxy = &server.ProxyProtocolOption{
+			TrustedCIDRs:  cidrs,
+			HeaderTimeout: cfg.Server.ProxyProtocol.HeaderTimeout,
+		}
+	}
+
+	var reactor *server.Reactor
+	if cfg.Server.Reactor.Enabled {
+		// Reactor handles the requests of a session one at a time.
+		if cfg.Server.Pipeline.Enabled {
+			return nil, errors.New("reactor can not be enabled along with pipeline")
+		}
+
+		reactor, err = server.NewReactor(server.ReactorOption{Workers: cfg.Server.Reactor.Workers})
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to new reactor")
+		}
+
+		if tlsConf != nil || proxy != nil {
+			logrus.Warn("Reactor only handles plain TCP connections, TLS or PROXY protocol ones fall back to goroutines")
+		}
+
+		tcpHandler.Reactor = reactor
+	}
+
+	tcpServer, err := server.NewTCPServer(cfg.Server.TCPEndpoint, tcpHandler, tlsConf, proxy)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to new TCP server")
 	}
 
+	var textServer *server.Server
+	if cfg.Server.Text.Enabled {
+		textServer, err = server.NewTextServer(cfg.Server.Text.Endpoint, connHandler)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to new text server")
+		}
+	}
+
 	restServer, err := rest.NewServer(cfg.Server.HTTPEndpoint, svcFactory)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to new RESTful server")
 	}
 
+	var wsServer *server.Server
+	if wsCfg := cfg.Server.WebSocket; wsCfg.Enabled {
+		wsHandler, err := newCodecConnectionHandler(connHandler, wsCfg.Codec)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to new WebSocket codec")
+		}
+
+		var wsListener *server.WebSocketListener
+		if len(wsCfg.Endpoint) > 0 {
+			wsListener, err = server.ListenWebSocket(wsCfg.Endpoint, wsCfg.Path)
+			if err != nil {
+				return nil, errors.WithMessage(err, "failed to new WebSocket listener")
+			}
+		} else {
+			wsListener = server.NewWebSocketListener(cfg.Server.HTTPEndpoint)
+			restServer.Mount(wsCfg.Path, wsListener)
+		}
+
+		wsServer = server.NewWebSocketServer(wsListener, wsHandler)
+	}
+
 	return &Application{
 		conf:       cfg,
 		sessionMgr: sessionMgr,
+		drainer:    drainer,
 		udpServer:  udpServer,
 		tcpServer:  tcpServer,
+		wsServer:   wsServer,
+		textServer: textServer,
 		restServer: restServer,
+		reactor:    reactor,
+		datagram:   datagram,
 	}, nil
 }
 
@@ -90,14 +263,205 @@ func (app *Application) Run() {
 	go app.sessionMgr.Start()
 	go app.udpServer.Serve()
 	go app.tcpServer.Serve()
+	if app.wsServer != nil {
+		go app.wsServer.Serve()
+	}
+	if app.textServer != nil {
+		go app.textServer.Serve()
+	}
+	if app.datagram != nil {
+		// Datagrams are served by the same handler chain as the connections.
+		go app.datagram.Serve(app.tcpServer.ConnectionHandler)
+	}
 	go app.restServer.Serve()
 
-	util.GracefulShutdown(&sync.WaitGroup{}, app.Close)
+	// Tell the previous process to drain itself if hot restarted.
+	if err := util.NotifyReady(); err != nil {
+		logrus.WithError(err).Warn("Failed to notify ready on hot restart")
+	}
+
+	if app.conf.Server.HotRestart.Enabled {
+		util.GracefulRestart(&sync.WaitGroup{}, app.restart, app.Close)
+	} else {
+		util.GracefulShutdown(&sync.WaitGroup{}, app.Close)
+	}
+}
+
+// restart starts the new binary inheriting the listening sockets, and returns
+// whether it took over, after which this process should drain itself.
+func (app *Application) restart() bool {
+	logrus.Info("Hot restarting")
+
+	handoff, err := util.StartProcess(app.conf.Server.HotRestart.ReadyTimeout)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to hot restart, keep serving")
+		return false
+	}
+
+	logrus.WithField("pid", handoff.Process.Pid).Info("New process took over, start draining")
+
+	app.handoff = handoff
+	return true
 }
 
 func (app *Application) Close() {
+	// Stop accepting new connections, and give in-flight requests a chance to finish.
+	app.udpServer.Drain()
+	app.tcpServer.Drain()
+	if app.wsServer != nil {
+		app.wsServer.Drain()
+	}
+	if app.textServer != nil {
+		app.textServer.Drain()
+	}
+	reason := drainReasonShutdown
+	if app.handoff != nil {
+		reason = drainReasonRestart
+	}
+	if err := app.drainer.Drain(app.sessionMgr, reason); err != nil {
+		logrus.WithError(err).Info("Server drain timed out")
+	}
+
 	app.sessionMgr.Stop()
 	app.udpServer.Close()
+	if app.datagram != nil {
+		app.datagram.Close()
+	}
+	if app.handoff != nil {
+		// New process starts serving UDP once the sockets no longer read here.
+		app.handoff.Release()
+	}
 	app.tcpServer.Close()
+	if app.reactor != nil {
+		app.reactor.Close()
+	}
+	if app.wsServer != nil {
+		app.wsServer.Close()
+	}
+	if app.textServer != nil {
+		app.textServer.Close()
+	}
+
+	// RESTful server is closed last to report the drain progress.
 	app.restServer.Close()
 }
+
+func newPipelineOption(cfg *config.PipelineConfig) (server.PipelineOption, error) {
+	orderedTypes := make(map[proto.MessageType]bool)
+	for _, t := range cfg.OrderedMessageTypes {
+		v, ok := proto.MessageType_value[strings.ToUpper(t)]
+		if !ok {
+			return server.PipelineOption{}, errors.Errorf("invalid message type %v", t)
+		}
+		orderedTypes[proto.MessageType(v)] = true
+	}
+
+	return server.PipelineOption{
+		Enabled:      cfg.Enabled,
+		MaxInFlight:  cfg.MaxInFlight,
+		OrderedTypes: orderedTypes,
+	}, nil
+}
+
+func newCompressionOption(cfg *config.CompressionConfig) (server.CompressionOption, error) {
+	var algorithms []proto.Compression
+	for _, a := range cfg.Algorithms {
+		v, ok := proto.Compression_value[strings.ToUpper(a)]
+		if !ok {
+			return server.CompressionOption{}, errors.Errorf("invalid compression %v", a)
+		}
+		algorithms = append(algorithms, proto.Compression(v))
+	}
+
+	return server.CompressionOption{
+		Algorithms: algorithms,
+		Threshold:  cfg.Threshold,
+	}, nil
+}
+
+func newOutboundOption(cfg *config.OutboundConfig) (server.OutboundOption, error) {
+	policy := strings.ToLower(cfg.SlowConsumerPolicy)
+	if !slices.Contains(server.SlowConsumerPolicies, policy) {
+		return server.OutboundOption{}, errors.Errorf("invalid slow consumer policy %v", cfg.SlowConsumerPolicy)
+	}
+
+	return server.OutboundOption{
+		QueueSize:    cfg.QueueSize,
+		WriteTimeout: cfg.WriteTimeout,
+		Policy:       policy,
+	}, nil
+}
+
+func newIPLimiter(cfg *config.IPLimitConfig) (*server.IPLimiter, error) {
+	if cfg.MaxConns <= 0 && cfg.AcceptRate <= 0 {
+		return nil, nil
+	}
+
+	exempt, err := util.ParseCIDRs(cfg.ExemptCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	return server.NewIPLimiter(server.IPLimitOption{
+		MaxConns:    cfg.MaxConns,
+		AcceptRate:  cfg.AcceptRate,
+		AcceptBurst: cfg.AcceptBurst,
+		Exempt:      exempt,
+	}), nil
+}
+
+func newHandshakeOption(
+	cfg *config.HandshakeConfig, compression server.CompressionOption) server.HandshakeOption {
+	capabilities := []string{
+		proto.CapabilityHeartbeat, proto.CapabilityShutdown, proto.CapabilityBatch,
+	}
+	if len(compression.Algorithms) > 0 {
+		capabilities = append(capabilities, proto.CapabilityCompression)
+	}
+
+	return server.HandshakeOption{
+		Required:           cfg.Required,
+		MinProtocolVersion: cfg.MinProtocolVersion,
+		Capabilities:       capabilities,
+	}
+}
+
+func newKCPOption(cfg *config.KCPConfig) (util.KCPOption, error) {
+	opt, err := util.NewKCPOptionFromPreset(cfg.Preset)
+	if err != nil {
+		return opt, err
+	}
+
+	overrideInt := func(dst *int, src *int) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	overrideBool := func(dst *bool, src *bool) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+
+	overrideBool(&opt.NoDelay, cfg.NoDelay)
+	overrideInt(&opt.Interval, cfg.Interval)
+	overrideInt(&opt.Resend, cfg.Resend)
+	overrideBool(&opt.NoCongestion, cfg.NoCongestion)
+	overrideInt(&opt.SndWnd, cfg.SndWnd)
+	overrideInt(&opt.RcvWnd, cfg.RcvWnd)
+	overrideInt(&opt.MTU, cfg.MTU)
+	overrideInt(&opt.DataShards, cfg.DataShards)
+	overrideInt(&opt.ParityShards, cfg.ParityShards)
+
+	return opt, nil
+}
+
+func newCodecConnectionHandler(
+	ch *server.ConnectionHandler, codecName string) (*server.ConnectionHandler, error) {
+	codec, err := proto.NewCodecByName(codecName)
+	if err != nil {
+		return nil, err
+	}
+
+	return ch.WithCodec(codec), nil
+}
@@ -89,7 +89,7 @@ func MsgValidator(next server.HandlerFunc) server.HandlerFunc {
 // Logger logs request, response and handling duration.
 func Logger(next server.HandlerFunc) server.HandlerFunc {
 	return func(ctx context.Context, m *server.Message) *server.Message {
-		if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		if !logrus.IsLevelEnabled(logrus.DebugLevel) {
 			// Skip logging if `debug` level is not enabled.
 			return next(ctx, m)
 		}
@@ -97,13 +97,17 @@ func Logger(next server.HandlerFunc) server.HandlerFunc {
 		// Start a timer
 		start := time.Now()
 
-		// Log the request
-		logrus.WithField("request", m.String()).Debug("Request received")
+		// Log the request along with the real client address
+		logger := logrus.NewEntry(logrus.StandardLogger())
+		if sess, ok := server.SessionFromContext(ctx); ok {
+			logger = logger.WithField("remoteAddr", sess.RemoteAddr())
+		}
+		logger.WithField("request", m.String()).Debug("Request received")
 
 		// Pass to next handler chain
 		resp := next(ctx, m)
 
-		logrus.WithFields(logrus.Fields{
+		logger.WithFields(logrus.Fields{
 			"response": protojson.Format(resp),
 			"elapsed":  time.Since(start),
 			"err":      resp.Error,
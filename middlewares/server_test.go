@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/server"
+)
+
+// proxiedConn reports the real client address, eg., carried by PROXY protocol.
+type proxiedConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c *proxiedConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+func TestLoggerRemoteAddr(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	level := logrus.GetLevel()
+	defer logrus.SetLevel(level)
+	logrus.SetLevel(logrus.DebugLevel)
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	remoteAddr := &net.TCPAddr{IP: net.IPv4(203, 0, 113, 7), Port: 50000}
+	sess := server.NewSession(&proxiedConn{Conn: conn, remoteAddr: remoteAddr}, proto.NewCodec())
+
+	handler := Logger(func(ctx context.Context, m *server.Message) *server.Message {
+		resp, _ := proto.NewResponseMessage(&proto.InfoResponse{})
+		return server.NewMessage(resp)
+	})
+
+	msg, _ := proto.NewRequestMessage(&proto.InfoRequest{})
+	handler(server.NewContextFromSession(context.Background(), sess), server.NewMessage(msg))
+
+	require.Len(t, hook.AllEntries(), 2)
+	for _, entry := range hook.AllEntries() {
+		assert.Equal(t, remoteAddr, entry.Data["remoteAddr"])
+	}
+}
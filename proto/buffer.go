@@ -0,0 +1,43 @@
+package proto
+
+import (
+	"sync"
+)
+
+const (
+	defaultBufferSize = 512
+	// Buffers larger than this are not pooled, so that occasional huge frames
+	// don't pin the memory.
+	maxPooledBufferSize = 64 << 10
+)
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, defaultBufferSize)
+		return &buf
+	},
+}
+
+// getBuffer gets an empty buffer from pool, which should be put back once done.
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+// putBuffer puts the buffer back to pool along with its grown capacity.
+func putBuffer(buf *[]byte, grown []byte) {
+	if cap(grown) > maxPooledBufferSize {
+		return
+	}
+
+	*buf = grown[:0]
+	bufferPool.Put(buf)
+}
+
+// grow returns the slice of n bytes, reusing the capacity of b if possible.
+func grow(b []byte, n int) []byte {
+	if cap(b) >= n {
+		return b[:n]
+	}
+
+	return make([]byte, n)
+}
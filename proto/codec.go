@@ -10,10 +10,59 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+const (
+	frameFlagShift = 24
+	maxFrameLength = 1<<frameFlagShift - 1
+)
+
+var (
+	// ErrFrameTooLarge is returned if the frame length exceeds the limit.
+	ErrFrameTooLarge = errors.New("frame too large")
+
+	// ErrUnexpectedCompression is returned when decoding a frame compressed other
+	// than the negotiated compression.
+	ErrUnexpectedCompression = errors.New("unexpected compression")
+)
+
+// FrameHeaderHook is implemented by the reader to be notified once the frame
+// length is decoded before reading the frame data, eg., to enforce the frame
+// size limit and arm the read deadline of the frame data. Returning an error
+// aborts decoding.
+type FrameHeaderHook interface {
+	OnFrameHeader(size int) error
+}
+
+// checkFrameHeader checks the decoded frame length before allocating for the
+// frame data, which is limited to 16MB at most.
+func checkFrameHeader(r io.Reader, size uint64) error {
+	if size > maxFrameLength {
+		return errors.WithMessagef(ErrFrameTooLarge, "%d bytes", size)
+	}
+
+	if h, ok := r.(FrameHeaderHook); ok {
+		return h.OnFrameHeader(int(size))
+	}
+
+	return nil
+}
+
+// MessageCodec serializes and deserializes protocol messages over the wire.
+type MessageCodec interface {
+	Encode(msg *Message, w io.Writer) error
+	Decode(r io.Reader) (*Message, error)
+}
+
 // Codec serializes and deserializes data between protocol message and underlying network package data.
+//
+// Each frame is prefixed with 4 bytes in big endian, whose highest byte is the
+// compression flag and the lower 3 bytes are the length of the frame data.
 type Codec struct {
 	proto.MarshalOptions
 	proto.UnmarshalOptions
+
+	Compression Compression // Compression to encode frames, and the only one accepted to decode
+	Threshold   int         // Frames smaller than the threshold stay uncompressed
+	MaxSize     int         // Max size of decompressed frame data, 0 means 16MB
 }
 
 func NewCodec() *Codec {
@@ -27,54 +76,124 @@ func NewCodec() *Codec {
 	}
 }
 
+// WithCompression returns a copy of the codec, which compresses the frames no
+// smaller than the threshold.
+func (c *Codec) WithCompression(compression Compression, threshold int) *Codec {
+	res := *c
+	res.Compression, res.Threshold = compression, threshold
+	return &res
+}
+
+// Encode writes the frame in a single write, with the buffers from pool to
+// avoid allocation per message.
 func (c *Codec) Encode(msg *Message, w io.Writer) error {
-	data, err := c.Marshal(msg)
+	buf := getBuffer()
+
+	// Reserve 4 bytes for the frame prefix ahead of the message data.
+	frame, err := c.MarshalAppend(append(*buf, 0, 0, 0, 0), msg)
 	if err != nil {
+		putBuffer(buf, frame)
 		return errors.WithMessage(err, "failed to marshal message")
 	}
+	defer func() { putBuffer(buf, frame) }()
 
-	// Write message length as the first 4 bytes in big endian.
-	if err := binary.Write(w, binary.BigEndian, int32(len(data))); err != nil {
-		return errors.WithMessage(err, "failed to write message length")
+	flag := Compression_NONE
+	if c.Compression != Compression_NONE && len(frame)-4 >= c.Threshold {
+		cbuf := getBuffer()
+		compressed, err := compress(c.Compression, append(*cbuf, 0, 0, 0, 0), frame[4:])
+		if err != nil {
+			putBuffer(cbuf, *cbuf)
+			return errors.WithMessage(err, "failed to compress message")
+		}
+
+		// Fall back to raw data if incompressible.
+		if len(compressed) < len(frame) {
+			flag, compressed, frame = c.Compression, frame, compressed
+		}
+		putBuffer(cbuf, compressed)
+	}
+
+	size := len(frame) - 4
+	if size > maxFrameLength {
+		return errors.Errorf("message too large (%d bytes)", size)
 	}
 
-	// Write message data.
-	if _, err := w.Write(data); err != nil {
+	// Write message length as the first 4 bytes in big endian along with the
+	// message data in a single write, so that message-oriented transports such
+	// as WebSocket carry the whole frame in one message.
+	binary.BigEndian.PutUint32(frame, uint32(flag)<<frameFlagShift|uint32(size))
+	if _, err := w.Write(frame); err != nil {
 		return errors.WithMessage(err, "failed to write msg data")
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"dataLen": len(data),
-		"dataHex": hex.EncodeToString(data),
-		"msg":     msg.String(),
-	}).Debug("Codec encodes message")
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.WithFields(logrus.Fields{
+			"dataLen":     size,
+			"compression": flag,
+			"dataHex":     hex.EncodeToString(frame[4:]),
+			"msg":         msg.String(),
+		}).Debug("Codec encodes message")
+	}
 
 	return nil
 }
 
+// Decode reads the frame into the buffers from pool, which are reused once the
+// message unmarshalled, since unmarshalling copies the data.
 func (c *Codec) Decode(r io.Reader) (*Message, error) {
-	// Read message length.
-	len := int32(0)
-	if err := binary.Read(r, binary.BigEndian, &len); err != nil {
+	buf := getBuffer()
+	data := grow(*buf, 4)
+	defer func() { putBuffer(buf, data) }()
+
+	// Read message length along with compression flag.
+	if _, err := io.ReadFull(r, data); err != nil {
 		return nil, errors.WithMessage(err, "failed to read message length")
 	}
 
-	// Read message data.
-	data := make([]byte, len)
-	if _, err := r.Read(data); err != nil {
+	prefix := binary.BigEndian.Uint32(data)
+	flag := Compression(prefix >> frameFlagShift)
+	size := prefix & maxFrameLength
+	if err := checkFrameHeader(r, uint64(size)); err != nil {
+		return nil, errors.WithMessage(err, "invalid message length")
+	}
+
+	// Read message data, which may arrive in several reads.
+	data = grow(data, int(size))
+	if _, err := io.ReadFull(r, data); err != nil {
 		return nil, errors.WithMessage(err, "failed to read message data")
 	}
 
+	payload := data
+	if flag != Compression_NONE {
+		// Only the negotiated compression is accepted, otherwise any peer could
+		// have the frames decompressed even though never negotiated.
+		if flag != c.Compression {
+			return nil, errors.WithMessagef(ErrUnexpectedCompression, "%v", flag)
+		}
+
+		dbuf := getBuffer()
+
+		var err error
+		if payload, err = decompress(flag, *dbuf, data, c.MaxSize); err != nil {
+			putBuffer(dbuf, *dbuf)
+			return nil, errors.WithMessage(err, "failed to decompress msg data")
+		}
+		defer putBuffer(dbuf, payload)
+	}
+
 	msg := new(Message)
-	if err := c.Unmarshal(data, msg); err != nil {
+	if err := c.Unmarshal(payload, msg); err != nil {
 		return nil, errors.WithMessage(err, "failed to unmarshal msg data")
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"dataLen": len,
-		"dataHex": hex.EncodeToString(data),
-		"msg":     msg.String(),
-	}).Debug("Codec decodes message")
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.WithFields(logrus.Fields{
+			"dataLen":     size,
+			"compression": flag,
+			"dataHex":     hex.EncodeToString(payload),
+			"msg":         msg.String(),
+		}).Debug("Codec decodes message")
+	}
 
 	return msg, nil
 }
@@ -0,0 +1,72 @@
+package proto
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func benchmarkMessage(b *testing.B) *Message {
+	// Map fields are left out, since deterministic marshalling allocates to
+	// sort the map keys regardless of the codec.
+	msg, err := NewResponseMessage(&InfoResponse{
+		ServerName:       strings.Repeat("cgo_game_server", 8),
+		Uptime:           "1h2m3s",
+		OnlinePlayers:    1000,
+		TotalConnections: 1200,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return msg
+}
+
+func benchmarkCodecEncode(b *testing.B, codec *Codec) {
+	msg := benchmarkMessage(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := codec.Encode(msg, io.Discard); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkCodecDecode(b *testing.B, codec *Codec) {
+	var buf bytes.Buffer
+	if err := codec.Encode(benchmarkMessage(b), &buf); err != nil {
+		b.Fatal(err)
+	}
+
+	r := bytes.NewReader(buf.Bytes())
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r.Reset(buf.Bytes())
+		if _, err := codec.Decode(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCodecEncode(b *testing.B) {
+	benchmarkCodecEncode(b, NewCodec())
+}
+
+func BenchmarkCodecDecode(b *testing.B) {
+	benchmarkCodecDecode(b, NewCodec())
+}
+
+func BenchmarkCodecEncodeSnappy(b *testing.B) {
+	benchmarkCodecEncode(b, NewCodec().WithCompression(Compression_SNAPPY, 0))
+}
+
+func BenchmarkCodecDecodeSnappy(b *testing.B) {
+	benchmarkCodecDecode(b, NewCodec().WithCompression(Compression_SNAPPY, 0))
+}
@@ -2,6 +2,8 @@ package proto
 
 import (
 	"bytes"
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -75,3 +77,93 @@ func TestCodecResponse(t *testing.T) {
 		)
 	}
 }
+
+func TestCodecsByName(t *testing.T) {
+	for _, name := range []string{CodecProtobuf, CodecVarintProtobuf, CodecJSON, CodecJSONLine} {
+		codec, err := NewCodecByName(name)
+		assert.NoError(t, err, "failed to new codec %v", name)
+
+		buf := bytes.NewBuffer(nil)
+
+		var msgs []*Message
+		for i := 0; i < 3; i++ {
+			msg, err := NewRequestMessage(&LoginRequest{
+				Username: "kokko", Password: "helloworld",
+			})
+			assert.NoError(t, err, "failed to new request message")
+
+			msg.Seq = uint64(i + 1)
+			msgs = append(msgs, msg)
+
+			assert.NoError(t, codec.Encode(msg, buf), "%v failed to encode message", name)
+		}
+
+		for _, msg := range msgs {
+			msg2, err := codec.Decode(buf)
+			assert.NoError(t, err, "%v failed to decode message", name)
+			assert.Equal(t, msg.String(), msg2.String(), "%v unmarshalled message mismatched", name)
+		}
+	}
+
+	_, err := NewCodecByName("xml")
+	assert.Error(t, err)
+}
+
+func TestCodecCompression(t *testing.T) {
+	metrics := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		metrics[fmt.Sprintf("rpc.rate.%d", i)] = "0.0"
+	}
+
+	msg, err := NewResponseMessage(&InfoResponse{ServerName: "cgo-game-server", Metrics: metrics})
+	assert.NoError(t, err, "failed to new response message")
+
+	for _, c := range []Compression{Compression_SNAPPY, Compression_ZSTD} {
+		// Statistics are process-wide, so only the differences are asserted.
+		before := GetCompressionStats(c)
+
+		for _, threshold := range []int{0, 1 << 20} {
+			codec := NewCodec().WithCompression(c, threshold)
+			buf := bytes.NewBuffer(nil)
+
+			assert.NoError(t, codec.Encode(msg, buf), "%v failed to encode message", c)
+			compressed := threshold == 0
+			assert.Equal(t, compressed, buf.Bytes()[0] == byte(c), "%v frame flag mismatched", c)
+
+			msg2, err := codec.Decode(buf)
+			assert.NoError(t, err, "%v failed to decode message", c)
+			assert.Equal(t, msg.String(), msg2.String(), "%v unmarshalled message mismatched", c)
+		}
+
+		after := GetCompressionStats(c)
+		assert.Greater(t, after.BytesIn-before.BytesIn, after.BytesOut-before.BytesOut)
+		assert.EqualValues(t, 1, after.NumCompress-before.NumCompress)
+		assert.EqualValues(t, 1, after.NumDecompress-before.NumDecompress)
+	}
+}
+
+func TestCodecDecompressionLimit(t *testing.T) {
+	msg, err := NewResponseMessage(&InfoResponse{ServerName: strings.Repeat("a", 4096)})
+	assert.NoError(t, err, "failed to new response message")
+
+	for _, c := range []Compression{Compression_SNAPPY, Compression_ZSTD} {
+		buf := bytes.NewBuffer(nil)
+		assert.NoError(t, NewCodec().WithCompression(c, 0).Encode(msg, buf), "%v failed to encode message", c)
+		assert.Less(t, buf.Len(), 1024, "%v frame not compressed", c)
+		frame := buf.Bytes()
+
+		// Compressed frames are rejected unless negotiated.
+		_, err := NewCodec().Decode(bytes.NewReader(frame))
+		assert.ErrorIs(t, err, ErrUnexpectedCompression, "%v frame decoded without negotiation", c)
+
+		// Decompressed size is limited rather than the compressed frame length.
+		codec := NewCodec().WithCompression(c, 0)
+		codec.MaxSize = 1024
+		_, err = codec.Decode(bytes.NewReader(frame))
+		assert.ErrorIs(t, err, ErrFrameTooLarge, "%v frame decompressed beyond limit", c)
+
+		codec.MaxSize = 8192
+		_, err = codec.Decode(bytes.NewReader(frame))
+		assert.NoError(t, err, "%v failed to decode message", c)
+	}
+}
@@ -0,0 +1,181 @@
+package proto
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	// Max decompressed size to guard against decompression bomb.
+	maxDecompressedSize = maxFrameLength
+
+	tplCompressTimerMetricKey   = "codec.compress.%s.time"
+	tplDecompressTimerMetricKey = "codec.decompress.%s.time"
+	tplCompressInMetricKey      = "codec.compress.%s.in"
+	tplCompressOutMetricKey     = "codec.compress.%s.out"
+)
+
+var (
+	errDecompressedTooLarge = errors.WithMessage(ErrFrameTooLarge, "decompressed data")
+
+	zstdOnce     sync.Once
+	zstdEncoder  *zstd.Encoder // Safe for concurrent `EncodeAll`
+	zstdDecoders sync.Map      // Max decompressed size => *zstd.Decoder, safe for concurrent `DecodeAll`
+
+	compressionMetricsCache sync.Map // Compression => *compressionMetrics
+)
+
+// compressionMetrics caches the metrics of compression algorithm, so as not to
+// format the metric keys for each frame.
+type compressionMetrics struct {
+	compressTimer   metrics.Timer
+	decompressTimer metrics.Timer
+	in              metrics.Counter
+	out             metrics.Counter
+}
+
+func getCompressionMetrics(c Compression) *compressionMetrics {
+	if v, ok := compressionMetricsCache.Load(c); ok {
+		return v.(*compressionMetrics)
+	}
+
+	v, _ := compressionMetricsCache.LoadOrStore(c, &compressionMetrics{
+		compressTimer:   metrics.GetOrRegisterTimer(compressTimerMetricKey(c), nil),
+		decompressTimer: metrics.GetOrRegisterTimer(decompressTimerMetricKey(c), nil),
+		in:              metrics.GetOrRegisterCounter(compressInMetricKey(c), nil),
+		out:             metrics.GetOrRegisterCounter(compressOutMetricKey(c), nil),
+	})
+	return v.(*compressionMetrics)
+}
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	})
+}
+
+// getZstdDecoder returns the decoder limited to the max decompressed size, which
+// is shared by the codecs with the same limit.
+func getZstdDecoder(limit int) *zstd.Decoder {
+	if v, ok := zstdDecoders.Load(limit); ok {
+		return v.(*zstd.Decoder)
+	}
+
+	d, _ := zstd.NewReader(
+		nil, zstd.WithDecoderMaxMemory(uint64(limit)), zstd.WithDecoderConcurrency(0),
+	)
+	if v, loaded := zstdDecoders.LoadOrStore(limit, d); loaded {
+		d.Close()
+		return v.(*zstd.Decoder)
+	}
+	return d
+}
+
+// compress appends the data compressed with the algorithm to dst.
+func compress(c Compression, dst, data []byte) ([]byte, error) {
+	m := getCompressionMetrics(c)
+	defer m.compressTimer.UpdateSince(time.Now())
+
+	var res []byte
+	switch c {
+	case Compression_SNAPPY:
+		// Snappy encodes in place only if dst is large enough.
+		n, buf := len(dst), dst[:cap(dst)]
+		if need := n + snappy.MaxEncodedLen(len(data)); len(buf) < need {
+			buf = append(dst, make([]byte, need-n)...)
+		}
+		res = buf[:n+len(snappy.Encode(buf[n:], data))]
+	case Compression_ZSTD:
+		initZstd()
+		res = zstdEncoder.EncodeAll(data, dst)
+	default:
+		return nil, errors.Errorf("unsupported compression %v", c)
+	}
+
+	m.in.Inc(int64(len(data)))
+	m.out.Inc(int64(len(res) - len(dst)))
+	return res, nil
+}
+
+// decompress decompresses the data with the algorithm into dst if large enough,
+// and fails if the decompressed data exceeds the limit, or 16MB if not positive.
+func decompress(c Compression, dst, data []byte, limit int) ([]byte, error) {
+	defer getCompressionMetrics(c).decompressTimer.UpdateSince(time.Now())
+
+	if limit <= 0 || limit > maxDecompressedSize {
+		limit = maxDecompressedSize
+	}
+
+	switch c {
+	case Compression_SNAPPY:
+		n, err := snappy.DecodedLen(data)
+		if err != nil {
+			return nil, err
+		}
+		if n > limit {
+			return nil, errDecompressedTooLarge
+		}
+		return snappy.Decode(dst[:cap(dst)], data)
+	case Compression_ZSTD:
+		res, err := getZstdDecoder(limit).DecodeAll(data, dst[:0])
+		if errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+			return nil, errDecompressedTooLarge
+		}
+		return res, err
+	default:
+		return nil, errors.Errorf("unsupported compression %v", c)
+	}
+}
+
+// CompressionStats is a snapshot of the compression statistics.
+type CompressionStats struct {
+	BytesIn        int64         // Total bytes before compression
+	BytesOut       int64         // Total bytes after compression
+	Ratio          float64       // Compression ratio of `BytesIn`/`BytesOut`
+	NumCompress    int64         // Number of compressed frames
+	CompressTime   time.Duration // Mean CPU time to compress a frame
+	NumDecompress  int64         // Number of decompressed frames
+	DecompressTime time.Duration // Mean CPU time to decompress a frame
+}
+
+// GetCompressionStats returns the statistics of the compression algorithm.
+func GetCompressionStats(c Compression) *CompressionStats {
+	m := getCompressionMetrics(c)
+	ct, dt := m.compressTimer.Snapshot(), m.decompressTimer.Snapshot()
+
+	stats := &CompressionStats{
+		BytesIn:        m.in.Count(),
+		BytesOut:       m.out.Count(),
+		NumCompress:    ct.Count(),
+		CompressTime:   time.Duration(ct.Mean()),
+		NumDecompress:  dt.Count(),
+		DecompressTime: time.Duration(dt.Mean()),
+	}
+	if stats.BytesOut > 0 {
+		stats.Ratio = float64(stats.BytesIn) / float64(stats.BytesOut)
+	}
+
+	return stats
+}
+
+func compressTimerMetricKey(c Compression) string {
+	return fmt.Sprintf(tplCompressTimerMetricKey, c)
+}
+
+func decompressTimerMetricKey(c Compression) string {
+	return fmt.Sprintf(tplDecompressTimerMetricKey, c)
+}
+
+func compressInMetricKey(c Compression) string {
+	return fmt.Sprintf(tplCompressInMetricKey, c)
+}
+
+func compressOutMetricKey(c Compression) string {
+	return fmt.Sprintf(tplCompressOutMetricKey, c)
+}
@@ -0,0 +1,46 @@
+package proto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const (
+	// DatagramKeySize is the size of the secret key bound by DATAGRAM_BIND.
+	DatagramKeySize = 32
+)
+
+// Directions of the datagram, which are authenticated as well, so that datagrams
+// can't be reflected back to the sender.
+const (
+	datagramFromClient byte = 'C'
+	datagramFromServer byte = 'S'
+)
+
+// Sign authenticates the datagram with the key bound by DATAGRAM_BIND.
+func (dg *Datagram) Sign(key []byte, fromServer bool) {
+	dg.Mac = dg.mac(key, fromServer)
+}
+
+// Verify checks if the datagram is authenticated with the key bound by DATAGRAM_BIND.
+func (dg *Datagram) Verify(key []byte, fromServer bool) bool {
+	return len(key) > 0 && hmac.Equal(dg.Mac, dg.mac(key, fromServer))
+}
+
+// mac computes HMAC-SHA256 of the direction, sequence number and message.
+func (dg *Datagram) mac(key []byte, fromServer bool) []byte {
+	var prefix [9]byte
+
+	prefix[0] = datagramFromClient
+	if fromServer {
+		prefix[0] = datagramFromServer
+	}
+	binary.BigEndian.PutUint64(prefix[1:], dg.Seq)
+
+	h := hmac.New(sha256.New, key)
+	h.Write(prefix[:])
+	h.Write(dg.Message)
+
+	return h.Sum(nil)
+}
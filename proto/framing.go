@@ -0,0 +1,185 @@
+package proto
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	CodecProtobuf       = "protobuf"        // 4-byte big endian length-prefixed protobuf
+	CodecVarintProtobuf = "varint-protobuf" // Varint length-prefixed protobuf
+	CodecJSON           = "json"            // 4-byte big endian length-prefixed protojson
+	CodecJSONLine       = "jsonline"        // Line-delimited protojson
+)
+
+// NewCodecByName creates the message codec by its wire format name.
+func NewCodecByName(name string) (MessageCodec, error) {
+	switch name {
+	case CodecProtobuf:
+		return NewCodec(), nil
+	case CodecVarintProtobuf:
+		return NewVarintCodec(), nil
+	case CodecJSON:
+		return NewJSONCodec(), nil
+	case CodecJSONLine:
+		return NewJSONLineCodec(), nil
+	default:
+		return nil, errors.Errorf("invalid codec %v", name)
+	}
+}
+
+// VarintCodec serializes and deserializes protobuf messages prefixed with the
+// varint encoded length, eg., `writeDelimitedTo` of protobuf Java.
+type VarintCodec struct {
+	proto.MarshalOptions
+	proto.UnmarshalOptions
+}
+
+func NewVarintCodec() *VarintCodec {
+	return &VarintCodec{
+		MarshalOptions: proto.MarshalOptions{
+			Deterministic: true, // use deterministic ordering for map fields
+		},
+		UnmarshalOptions: proto.UnmarshalOptions{
+			DiscardUnknown: true, // discard unknown fields
+		},
+	}
+}
+
+func (c *VarintCodec) Encode(msg *Message, w io.Writer) error {
+	buf := getBuffer()
+	frame := binary.AppendUvarint(*buf, uint64(c.Size(msg)))
+	defer func() { putBuffer(buf, frame) }()
+
+	frame, err := c.MarshalAppend(frame, msg)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal message")
+	}
+
+	if _, err := w.Write(frame); err != nil {
+		return errors.WithMessage(err, "failed to write msg data")
+	}
+
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.WithField("msg", msg.String()).Debug("Codec encodes message")
+	}
+	return nil
+}
+
+func (c *VarintCodec) Decode(r io.Reader) (*Message, error) {
+	size, err := binary.ReadUvarint(asByteReader(r))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read message length")
+	}
+	if err := checkFrameHeader(r, size); err != nil {
+		return nil, errors.WithMessage(err, "invalid message length")
+	}
+
+	buf := getBuffer()
+	data := grow(*buf, int(size))
+	defer func() { putBuffer(buf, data) }()
+
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errors.WithMessage(err, "failed to read message data")
+	}
+
+	msg := new(Message)
+	if err := c.Unmarshal(data, msg); err != nil {
+		return nil, errors.WithMessage(err, "failed to unmarshal msg data")
+	}
+
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.WithField("msg", msg.String()).Debug("Codec decodes message")
+	}
+	return msg, nil
+}
+
+// JSONCodec serializes and deserializes protojson messages prefixed with the
+// 4-byte big endian length.
+type JSONCodec struct {
+	protojson.MarshalOptions
+	protojson.UnmarshalOptions
+}
+
+func NewJSONCodec() *JSONCodec {
+	return &JSONCodec{
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: true, // discard unknown fields
+		},
+	}
+}
+
+func (c *JSONCodec) Encode(msg *Message, w io.Writer) error {
+	data, err := c.Marshal(msg)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal message")
+	}
+
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+
+	if _, err := w.Write(frame); err != nil {
+		return errors.WithMessage(err, "failed to write msg data")
+	}
+
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.WithField("msg", msg.String()).Debug("Codec encodes message")
+	}
+	return nil
+}
+
+func (c *JSONCodec) Decode(r io.Reader) (*Message, error) {
+	var size uint32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, errors.WithMessage(err, "failed to read message length")
+	}
+	if err := checkFrameHeader(r, uint64(size)); err != nil {
+		return nil, errors.WithMessage(err, "invalid message length")
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errors.WithMessage(err, "failed to read message data")
+	}
+
+	msg := new(Message)
+	if err := c.Unmarshal(data, msg); err != nil {
+		// The frame is intact, so that the following messages can still be decoded.
+		return nil, &MalformedError{
+			Err: errors.WithMessage(err, "failed to unmarshal msg data"),
+		}
+	}
+
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.WithField("msg", msg.String()).Debug("Codec decodes message")
+	}
+	return msg, nil
+}
+
+// byteReader reads byte by byte from the unbuffered reader.
+type byteReader struct {
+	io.Reader
+	b [1]byte
+}
+
+func (r *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(r.Reader, r.b[:]); err != nil {
+		return 0, err
+	}
+
+	return r.b[0], nil
+}
+
+func asByteReader(r io.Reader) io.ByteReader {
+	if br, ok := r.(io.ByteReader); ok {
+		return br
+	}
+
+	return &byteReader{Reader: r}
+}
@@ -0,0 +1,15 @@
+package proto
+
+const (
+	// ProtocolVersion is the current version of the protocol, which should be
+	// bumped on any incompatible change.
+	ProtocolVersion uint32 = 1
+)
+
+// Feature capabilities exchanged by the HELLO handshake.
+const (
+	CapabilityHeartbeat   = "heartbeat"   // PING heartbeat with agreed liveness policy
+	CapabilityCompression = "compression" // NEGOTIATE per-frame compression
+	CapabilityShutdown    = "shutdown"    // SHUTDOWN event before server drains
+	CapabilityBatch       = "batch"       // BATCH envelope carrying several messages
+)
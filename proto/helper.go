@@ -11,6 +11,7 @@ var (
 )
 
 func NewResponseMessage(msg proto.Message) (*Message, error) {
+	var msgType MessageType
 	resp := &Response{}
 	switch v := msg.(type) {
 	case *Status:
@@ -23,15 +24,63 @@ func NewResponseMessage(msg proto.Message) (*Message, error) {
 		resp.Body = &Response_Logout{v}
 	case *GenerateRandomNicknameResponse:
 		resp.Body = &Response_GenerateRandomNickname{v}
+	case *LoginQueueStatus:
+		resp.Body = &Response_LoginQueue{v}
+	case *PongResponse:
+		msgType = MessageType_PONG
+		resp.Body = &Response_Pong{v}
+	case *NegotiateResponse:
+		msgType = MessageType_NEGOTIATE
+		resp.Body = &Response_Negotiate{v}
+	case *HelloResponse:
+		msgType = MessageType_HELLO
+		resp.Body = &Response_Hello{v}
+	case *DatagramBindResponse:
+		msgType = MessageType_DATAGRAM_BIND
+		resp.Body = &Response_DatagramBind{v}
 	// TODO: extend for more message types support
 	default:
 		return nil, invalidProtoMessage
 	}
 
-	res := &Message{Body: &Message_Response{resp}}
+	res := &Message{
+		Type: msgType,
+		Body: &Message_Response{resp},
+	}
+	return res, nil
+}
+
+func NewEventMessage(msg proto.Message) (*Message, error) {
+	event := &Event{}
+	switch v := msg.(type) {
+	case *KickedEvent:
+		event.Body = &Event_Kicked{v}
+	case *LoginQueueStatus:
+		event.Body = &Event_LoginQueue{v}
+	case *LoginResponse:
+		event.Body = &Event_Login{v}
+	case *ShutdownEvent:
+		event.Body = &Event_Shutdown{v}
+	// TODO: extend for more event types support
+	default:
+		return nil, invalidProtoMessage
+	}
+
+	res := &Message{
+		Type: MessageType_EVENT,
+		Body: &Message_Event{event},
+	}
 	return res, nil
 }
 
+// NewBatchMessage wraps the messages in a batch envelope carried by one frame.
+func NewBatchMessage(msgs ...*Message) *Message {
+	return &Message{
+		Type: MessageType_BATCH,
+		Body: &Message_Batch{&Batch{Messages: msgs}},
+	}
+}
+
 func NewRequestMessage(msg proto.Message) (*Message, error) {
 	var msgType MessageType
 	request := &Request{}
@@ -49,6 +98,18 @@ func NewRequestMessage(msg proto.Message) (*Message, error) {
 	case *GenerateRandomNicknameRequest:
 		msgType = MessageType_GENERATE_RANDOM_NICKNAME
 		request.Body = &Request_GenerateRandomNickname{v}
+	case *PingRequest:
+		msgType = MessageType_PING
+		request.Body = &Request_Ping{v}
+	case *NegotiateRequest:
+		msgType = MessageType_NEGOTIATE
+		request.Body = &Request_Negotiate{v}
+	case *HelloRequest:
+		msgType = MessageType_HELLO
+		request.Body = &Request_Hello{v}
+	case *DatagramBindRequest:
+		msgType = MessageType_DATAGRAM_BIND
+		request.Body = &Request_DatagramBind{v}
 	// TODO: extend for more message types support
 	default:
 		return nil, invalidProtoMessage
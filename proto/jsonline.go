@@ -0,0 +1,125 @@
+package proto
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// MalformedError indicates the framed message is malformed, while the following
+// messages can still be decoded.
+type MalformedError struct {
+	Err error
+}
+
+func (e *MalformedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MalformedError) Unwrap() error {
+	return e.Err
+}
+
+// lineReader reads until the delimiter, eg., `bufio.Reader`.
+type lineReader interface {
+	ReadSlice(delim byte) ([]byte, error)
+}
+
+// JSONLineCodec serializes and deserializes protocol messages as protojson text,
+// one message per line, eg., to drive the server with netcat.
+type JSONLineCodec struct {
+	protojson.MarshalOptions
+	protojson.UnmarshalOptions
+}
+
+func NewJSONLineCodec() *JSONLineCodec {
+	return &JSONLineCodec{
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: true, // discard unknown fields
+		},
+	}
+}
+
+func (c *JSONLineCodec) Encode(msg *Message, w io.Writer) error {
+	data, err := c.Marshal(msg)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal message")
+	}
+
+	// Write message along with the line delimiter in a single write.
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return errors.WithMessage(err, "failed to write msg data")
+	}
+
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.WithField("msg", msg.String()).Debug("Codec encodes message")
+	}
+	return nil
+}
+
+// Decode reads the next non-empty line as the message. The reader should be
+// buffered with `ReadSlice` method for efficiency, otherwise read byte by byte.
+func (c *JSONLineCodec) Decode(r io.Reader) (*Message, error) {
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to read message line")
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 { // Skip empty line
+			continue
+		}
+
+		msg := new(Message)
+		if err := c.Unmarshal(line, msg); err != nil {
+			return nil, &MalformedError{
+				Err: errors.WithMessage(err, "failed to unmarshal msg data"),
+			}
+		}
+
+		if logrus.IsLevelEnabled(logrus.DebugLevel) {
+			logrus.WithField("msg", msg.String()).Debug("Codec decodes message")
+		}
+		return msg, nil
+	}
+}
+
+func readLine(r io.Reader) ([]byte, error) {
+	if lr, ok := r.(lineReader); ok {
+		line, err := lr.ReadSlice('\n')
+		if err == nil || (err == io.EOF && len(line) > 0) {
+			return line, nil
+		}
+
+		if err == bufio.ErrBufferFull {
+			return nil, errors.WithMessagef(ErrFrameTooLarge, "line exceeds %d bytes", len(line))
+		}
+
+		return nil, err
+	}
+
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(r, b); err != nil {
+			if err == io.EOF && len(line) > 0 {
+				return line, nil
+			}
+			return nil, err
+		}
+
+		if b[0] == '\n' {
+			return line, nil
+		}
+
+		if len(line) >= maxFrameLength {
+			return nil, errors.WithMessagef(ErrFrameTooLarge, "line exceeds %d bytes", len(line))
+		}
+		line = append(line, b[0])
+	}
+}
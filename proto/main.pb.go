@@ -25,25 +25,46 @@ const (
 type MessageType int32
 
 const (
-	MessageType_INFO                     MessageType = 0 // INFO command
-	MessageType_LOGIN                    MessageType = 1 // LOGIN command
-	MessageType_LOGOUT                   MessageType = 2 // LOGOUT command
-	MessageType_GENERATE_RANDOM_NICKNAME MessageType = 3 // GENERATE_RANDOM_NICKNAME command
+	MessageType_INFO                     MessageType = 0  // INFO command
+	MessageType_LOGIN                    MessageType = 1  // LOGIN command
+	MessageType_LOGOUT                   MessageType = 2  // LOGOUT command
+	MessageType_GENERATE_RANDOM_NICKNAME MessageType = 3  // GENERATE_RANDOM_NICKNAME command
+	MessageType_EVENT                    MessageType = 4  // Unsolicited server event
+	MessageType_PING                     MessageType = 5  // PING heartbeat
+	MessageType_PONG                     MessageType = 6  // PONG heartbeat
+	MessageType_NEGOTIATE                MessageType = 7  // NEGOTIATE per-frame compression
+	MessageType_HELLO                    MessageType = 8  // HELLO handshake as the first message on every connection
+	MessageType_BATCH                    MessageType = 9  // BATCH envelope carrying several messages in one frame
+	MessageType_DATAGRAM_BIND            MessageType = 10 // DATAGRAM_BIND the unreliable datagram channel to the session
 )
 
 // Enum value maps for MessageType.
 var (
 	MessageType_name = map[int32]string{
-		0: "INFO",
-		1: "LOGIN",
-		2: "LOGOUT",
-		3: "GENERATE_RANDOM_NICKNAME",
+		0:  "INFO",
+		1:  "LOGIN",
+		2:  "LOGOUT",
+		3:  "GENERATE_RANDOM_NICKNAME",
+		4:  "EVENT",
+		5:  "PING",
+		6:  "PONG",
+		7:  "NEGOTIATE",
+		8:  "HELLO",
+		9:  "BATCH",
+		10: "DATAGRAM_BIND",
 	}
 	MessageType_value = map[string]int32{
 		"INFO":                     0,
 		"LOGIN":                    1,
 		"LOGOUT":                   2,
 		"GENERATE_RANDOM_NICKNAME": 3,
+		"EVENT":                    4,
+		"PING":                     5,
+		"PONG":                     6,
+		"NEGOTIATE":                7,
+		"HELLO":                    8,
+		"BATCH":                    9,
+		"DATAGRAM_BIND":            10,
 	}
 )
 
@@ -74,6 +95,56 @@ func (MessageType) EnumDescriptor() ([]byte, []int) {
 	return file_main_proto_rawDescGZIP(), []int{0}
 }
 
+// Per-frame compression algorithm
+type Compression int32
+
+const (
+	Compression_NONE   Compression = 0 // No compression
+	Compression_SNAPPY Compression = 1 // Snappy compression
+	Compression_ZSTD   Compression = 2 // Zstandard compression
+)
+
+// Enum value maps for Compression.
+var (
+	Compression_name = map[int32]string{
+		0: "NONE",
+		1: "SNAPPY",
+		2: "ZSTD",
+	}
+	Compression_value = map[string]int32{
+		"NONE":   0,
+		"SNAPPY": 1,
+		"ZSTD":   2,
+	}
+)
+
+func (x Compression) Enum() *Compression {
+	p := new(Compression)
+	*p = x
+	return p
+}
+
+func (x Compression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Compression) Descriptor() protoreflect.EnumDescriptor {
+	return file_main_proto_enumTypes[1].Descriptor()
+}
+
+func (Compression) Type() protoreflect.EnumType {
+	return &file_main_proto_enumTypes[1]
+}
+
+func (x Compression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Compression.Descriptor instead.
+func (Compression) EnumDescriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{1}
+}
+
 type LoginRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -167,6 +238,63 @@ func (*LoginResponse) Descriptor() ([]byte, []int) {
 	return file_main_proto_rawDescGZIP(), []int{1}
 }
 
+// Login queue status, responded or pushed to the player waiting in line
+// when the server is full.
+type LoginQueueStatus struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Position   int32 `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`                       // 1-based position in the login queue
+	EtaSeconds int64 `protobuf:"varint,2,opt,name=eta_seconds,json=etaSeconds,proto3" json:"eta_seconds,omitempty"` // estimated seconds to wait, 0 if unknown
+}
+
+func (x *LoginQueueStatus) Reset() {
+	*x = LoginQueueStatus{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LoginQueueStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginQueueStatus) ProtoMessage() {}
+
+func (x *LoginQueueStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginQueueStatus.ProtoReflect.Descriptor instead.
+func (*LoginQueueStatus) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LoginQueueStatus) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *LoginQueueStatus) GetEtaSeconds() int64 {
+	if x != nil {
+		return x.EtaSeconds
+	}
+	return 0
+}
+
 type LogoutRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -176,7 +304,7 @@ type LogoutRequest struct {
 func (x *LogoutRequest) Reset() {
 	*x = LogoutRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[2]
+		mi := &file_main_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -189,7 +317,7 @@ func (x *LogoutRequest) String() string {
 func (*LogoutRequest) ProtoMessage() {}
 
 func (x *LogoutRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[2]
+	mi := &file_main_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -202,7 +330,7 @@ func (x *LogoutRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogoutRequest.ProtoReflect.Descriptor instead.
 func (*LogoutRequest) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{2}
+	return file_main_proto_rawDescGZIP(), []int{3}
 }
 
 type LogoutResponse struct {
@@ -214,7 +342,7 @@ type LogoutResponse struct {
 func (x *LogoutResponse) Reset() {
 	*x = LogoutResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[3]
+		mi := &file_main_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -227,7 +355,7 @@ func (x *LogoutResponse) String() string {
 func (*LogoutResponse) ProtoMessage() {}
 
 func (x *LogoutResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[3]
+	mi := &file_main_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -240,7 +368,7 @@ func (x *LogoutResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogoutResponse.ProtoReflect.Descriptor instead.
 func (*LogoutResponse) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{3}
+	return file_main_proto_rawDescGZIP(), []int{4}
 }
 
 type InfoRequest struct {
@@ -252,7 +380,7 @@ type InfoRequest struct {
 func (x *InfoRequest) Reset() {
 	*x = InfoRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[4]
+		mi := &file_main_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -265,7 +393,7 @@ func (x *InfoRequest) String() string {
 func (*InfoRequest) ProtoMessage() {}
 
 func (x *InfoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[4]
+	mi := &file_main_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -278,7 +406,7 @@ func (x *InfoRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
 func (*InfoRequest) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{4}
+	return file_main_proto_rawDescGZIP(), []int{5}
 }
 
 type InfoResponse struct {
@@ -290,13 +418,13 @@ type InfoResponse struct {
 	Uptime           string            `protobuf:"bytes,2,opt,name=uptime,proto3" json:"uptime,omitempty"`                                                                                           // server uptime
 	OnlinePlayers    int32             `protobuf:"varint,3,opt,name=online_players,json=onlinePlayers,proto3" json:"online_players,omitempty"`                                                       // number of online players
 	TotalConnections int32             `protobuf:"varint,4,opt,name=total_connections,json=totalConnections,proto3" json:"total_connections,omitempty"`                                              // total number of network connections
-	Metrics          map[string]string `protobuf:"bytes,5,rep,name=metrics,proto3" json:"metrics,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"` // metrics status as key-value pairs
+	Metrics          map[string]string `protobuf:"bytes,5,rep,name=metrics,proto3" json:"metrics,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"` // overall metrics as key-value pairs
 }
 
 func (x *InfoResponse) Reset() {
 	*x = InfoResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[5]
+		mi := &file_main_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -309,7 +437,7 @@ func (x *InfoResponse) String() string {
 func (*InfoResponse) ProtoMessage() {}
 
 func (x *InfoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[5]
+	mi := &file_main_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -322,7 +450,7 @@ func (x *InfoResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
 func (*InfoResponse) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{5}
+	return file_main_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *InfoResponse) GetServerName() string {
@@ -372,7 +500,7 @@ type GenerateRandomNicknameRequest struct {
 func (x *GenerateRandomNicknameRequest) Reset() {
 	*x = GenerateRandomNicknameRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[6]
+		mi := &file_main_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -385,7 +513,7 @@ func (x *GenerateRandomNicknameRequest) String() string {
 func (*GenerateRandomNicknameRequest) ProtoMessage() {}
 
 func (x *GenerateRandomNicknameRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[6]
+	mi := &file_main_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -398,7 +526,7 @@ func (x *GenerateRandomNicknameRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GenerateRandomNicknameRequest.ProtoReflect.Descriptor instead.
 func (*GenerateRandomNicknameRequest) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{6}
+	return file_main_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GenerateRandomNicknameRequest) GetSex() int32 {
@@ -426,7 +554,7 @@ type GenerateRandomNicknameResponse struct {
 func (x *GenerateRandomNicknameResponse) Reset() {
 	*x = GenerateRandomNicknameResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[7]
+		mi := &file_main_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -439,7 +567,7 @@ func (x *GenerateRandomNicknameResponse) String() string {
 func (*GenerateRandomNicknameResponse) ProtoMessage() {}
 
 func (x *GenerateRandomNicknameResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[7]
+	mi := &file_main_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -452,7 +580,7 @@ func (x *GenerateRandomNicknameResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GenerateRandomNicknameResponse.ProtoReflect.Descriptor instead.
 func (*GenerateRandomNicknameResponse) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{7}
+	return file_main_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *GenerateRandomNicknameResponse) GetNickname() string {
@@ -462,38 +590,31 @@ func (x *GenerateRandomNicknameResponse) GetNickname() string {
 	return ""
 }
 
-// Message for encapsulating different request types
-type Request struct {
+type PingRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to Body:
-	//
-	//	*Request_Info
-	//	*Request_Login
-	//	*Request_Logout
-	//	*Request_GenerateRandomNickname
-	Body isRequest_Body `protobuf_oneof:"body"`
+	Timestamp int64 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Client timestamp in milliseconds
 }
 
-func (x *Request) Reset() {
-	*x = Request{}
+func (x *PingRequest) Reset() {
+	*x = PingRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[8]
+		mi := &file_main_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Request) String() string {
+func (x *PingRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Request) ProtoMessage() {}
+func (*PingRequest) ProtoMessage() {}
 
-func (x *Request) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[8]
+func (x *PingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -504,101 +625,109 @@ func (x *Request) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Request.ProtoReflect.Descriptor instead.
-func (*Request) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use PingRequest.ProtoReflect.Descriptor instead.
+func (*PingRequest) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{9}
 }
 
-func (m *Request) GetBody() isRequest_Body {
-	if m != nil {
-		return m.Body
+func (x *PingRequest) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
 	}
-	return nil
+	return 0
 }
 
-func (x *Request) GetInfo() *InfoRequest {
-	if x, ok := x.GetBody().(*Request_Info); ok {
-		return x.Info
-	}
-	return nil
-}
+type PongResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-func (x *Request) GetLogin() *LoginRequest {
-	if x, ok := x.GetBody().(*Request_Login); ok {
-		return x.Login
-	}
-	return nil
+	Timestamp  int64 `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                     // Echoed client timestamp in milliseconds
+	IntervalMs int64 `protobuf:"varint,2,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"` // Heartbeat interval agreed by server
+	TimeoutMs  int64 `protobuf:"varint,3,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`    // Liveness timeout agreed by server
 }
 
-func (x *Request) GetLogout() *LogoutRequest {
-	if x, ok := x.GetBody().(*Request_Logout); ok {
-		return x.Logout
+func (x *PongResponse) Reset() {
+	*x = PongResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return nil
 }
 
-func (x *Request) GetGenerateRandomNickname() *GenerateRandomNicknameRequest {
-	if x, ok := x.GetBody().(*Request_GenerateRandomNickname); ok {
-		return x.GenerateRandomNickname
-	}
-	return nil
+func (x *PongResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-type isRequest_Body interface {
-	isRequest_Body()
-}
+func (*PongResponse) ProtoMessage() {}
 
-type Request_Info struct {
-	Info *InfoRequest `protobuf:"bytes,1,opt,name=info,proto3,oneof"`
+func (x *PongResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-type Request_Login struct {
-	Login *LoginRequest `protobuf:"bytes,2,opt,name=login,proto3,oneof"`
+// Deprecated: Use PongResponse.ProtoReflect.Descriptor instead.
+func (*PongResponse) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{10}
 }
 
-type Request_Logout struct {
-	Logout *LogoutRequest `protobuf:"bytes,3,opt,name=logout,proto3,oneof"`
+func (x *PongResponse) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
 }
 
-type Request_GenerateRandomNickname struct {
-	GenerateRandomNickname *GenerateRandomNicknameRequest `protobuf:"bytes,4,opt,name=generate_random_nickname,json=generateRandomNickname,proto3,oneof"`
+func (x *PongResponse) GetIntervalMs() int64 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
 }
 
-func (*Request_Info) isRequest_Body() {}
-
-func (*Request_Login) isRequest_Body() {}
-
-func (*Request_Logout) isRequest_Body() {}
-
-func (*Request_GenerateRandomNickname) isRequest_Body() {}
+func (x *PongResponse) GetTimeoutMs() int64 {
+	if x != nil {
+		return x.TimeoutMs
+	}
+	return 0
+}
 
-// Message for conveying response status information
-type Status struct {
+type HelloRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Code    int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`      // Status code
-	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"` // Descriptive message
+	ProtocolVersion uint32   `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"` // Protocol version spoken by client
+	ClientVersion   string   `protobuf:"bytes,2,opt,name=client_version,json=clientVersion,proto3" json:"client_version,omitempty"`        // Client build version
+	Platform        string   `protobuf:"bytes,3,opt,name=platform,proto3" json:"platform,omitempty"`                                       // Client platform, eg., "linux/amd64", "ios", "web"
+	Capabilities    []string `protobuf:"bytes,4,rep,name=capabilities,proto3" json:"capabilities,omitempty"`                               // Feature capabilities supported by client
 }
 
-func (x *Status) Reset() {
-	*x = Status{}
+func (x *HelloRequest) Reset() {
+	*x = HelloRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[9]
+		mi := &file_main_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Status) String() string {
+func (x *HelloRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Status) ProtoMessage() {}
+func (*HelloRequest) ProtoMessage() {}
 
-func (x *Status) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[9]
+func (x *HelloRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -609,58 +738,65 @@ func (x *Status) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Status.ProtoReflect.Descriptor instead.
-func (*Status) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use HelloRequest.ProtoReflect.Descriptor instead.
+func (*HelloRequest) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *Status) GetCode() int32 {
+func (x *HelloRequest) GetProtocolVersion() uint32 {
 	if x != nil {
-		return x.Code
+		return x.ProtocolVersion
 	}
 	return 0
 }
 
-func (x *Status) GetMessage() string {
+func (x *HelloRequest) GetClientVersion() string {
 	if x != nil {
-		return x.Message
+		return x.ClientVersion
 	}
 	return ""
 }
 
-// Message for encapsulating different response types
-type Response struct {
+func (x *HelloRequest) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *HelloRequest) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type HelloResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	// Types that are assignable to Body:
-	//
-	//	*Response_Status
-	//	*Response_Info
-	//	*Response_Login
-	//	*Response_Logout
-	//	*Response_GenerateRandomNickname
-	Body isResponse_Body `protobuf_oneof:"body"`
+	ProtocolVersion uint32   `protobuf:"varint,1,opt,name=protocol_version,json=protocolVersion,proto3" json:"protocol_version,omitempty"` // Protocol version spoken by server
+	Capabilities    []string `protobuf:"bytes,2,rep,name=capabilities,proto3" json:"capabilities,omitempty"`                               // Feature capabilities supported by both client and server
 }
 
-func (x *Response) Reset() {
-	*x = Response{}
+func (x *HelloResponse) Reset() {
+	*x = HelloResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[10]
+		mi := &file_main_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Response) String() string {
+func (x *HelloResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Response) ProtoMessage() {}
+func (*HelloResponse) ProtoMessage() {}
 
-func (x *Response) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[10]
+func (x *HelloResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -671,20 +807,512 @@ func (x *Response) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Response.ProtoReflect.Descriptor instead.
-func (*Response) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use HelloResponse.ProtoReflect.Descriptor instead.
+func (*HelloResponse) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{12}
 }
 
-func (m *Response) GetBody() isResponse_Body {
-	if m != nil {
-		return m.Body
+func (x *HelloResponse) GetProtocolVersion() uint32 {
+	if x != nil {
+		return x.ProtocolVersion
 	}
-	return nil
+	return 0
 }
 
-func (x *Response) GetStatus() *Status {
-	if x, ok := x.GetBody().(*Response_Status); ok {
+func (x *HelloResponse) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type NegotiateRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Compressions []Compression `protobuf:"varint,1,rep,packed,name=compressions,proto3,enum=main.Compression" json:"compressions,omitempty"` // Supported compressions in preference order
+}
+
+func (x *NegotiateRequest) Reset() {
+	*x = NegotiateRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NegotiateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NegotiateRequest) ProtoMessage() {}
+
+func (x *NegotiateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NegotiateRequest.ProtoReflect.Descriptor instead.
+func (*NegotiateRequest) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *NegotiateRequest) GetCompressions() []Compression {
+	if x != nil {
+		return x.Compressions
+	}
+	return nil
+}
+
+type NegotiateResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Compression Compression `protobuf:"varint,1,opt,name=compression,proto3,enum=main.Compression" json:"compression,omitempty"` // Compression agreed by server, NONE if not supported
+	Threshold   int32       `protobuf:"varint,2,opt,name=threshold,proto3" json:"threshold,omitempty"`                           // Frames smaller than the threshold in bytes stay uncompressed
+}
+
+func (x *NegotiateResponse) Reset() {
+	*x = NegotiateResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NegotiateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NegotiateResponse) ProtoMessage() {}
+
+func (x *NegotiateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NegotiateResponse.ProtoReflect.Descriptor instead.
+func (*NegotiateResponse) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *NegotiateResponse) GetCompression() Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return Compression_NONE
+}
+
+func (x *NegotiateResponse) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+type DatagramBindRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DatagramBindRequest) Reset() {
+	*x = DatagramBindRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DatagramBindRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DatagramBindRequest) ProtoMessage() {}
+
+func (x *DatagramBindRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DatagramBindRequest.ProtoReflect.Descriptor instead.
+func (*DatagramBindRequest) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{15}
+}
+
+type DatagramBindResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token    []byte `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`       // Session token carried by every datagram sent by client
+	Endpoint string `protobuf:"bytes,2,opt,name=endpoint,proto3" json:"endpoint,omitempty"` // UDP endpoint of the datagram channel, empty host for the same one
+	Key      []byte `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`           // Secret key to authenticate the datagrams of both sides, never sent over the datagram channel
+}
+
+func (x *DatagramBindResponse) Reset() {
+	*x = DatagramBindResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DatagramBindResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DatagramBindResponse) ProtoMessage() {}
+
+func (x *DatagramBindResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DatagramBindResponse.ProtoReflect.Descriptor instead.
+func (*DatagramBindResponse) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DatagramBindResponse) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *DatagramBindResponse) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *DatagramBindResponse) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+// Message for encapsulating different request types
+type Request struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Body:
+	//
+	//	*Request_Info
+	//	*Request_Login
+	//	*Request_Logout
+	//	*Request_GenerateRandomNickname
+	//	*Request_Ping
+	//	*Request_Negotiate
+	//	*Request_Hello
+	//	*Request_DatagramBind
+	Body isRequest_Body `protobuf_oneof:"body"`
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{17}
+}
+
+func (m *Request) GetBody() isRequest_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (x *Request) GetInfo() *InfoRequest {
+	if x, ok := x.GetBody().(*Request_Info); ok {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *Request) GetLogin() *LoginRequest {
+	if x, ok := x.GetBody().(*Request_Login); ok {
+		return x.Login
+	}
+	return nil
+}
+
+func (x *Request) GetLogout() *LogoutRequest {
+	if x, ok := x.GetBody().(*Request_Logout); ok {
+		return x.Logout
+	}
+	return nil
+}
+
+func (x *Request) GetGenerateRandomNickname() *GenerateRandomNicknameRequest {
+	if x, ok := x.GetBody().(*Request_GenerateRandomNickname); ok {
+		return x.GenerateRandomNickname
+	}
+	return nil
+}
+
+func (x *Request) GetPing() *PingRequest {
+	if x, ok := x.GetBody().(*Request_Ping); ok {
+		return x.Ping
+	}
+	return nil
+}
+
+func (x *Request) GetNegotiate() *NegotiateRequest {
+	if x, ok := x.GetBody().(*Request_Negotiate); ok {
+		return x.Negotiate
+	}
+	return nil
+}
+
+func (x *Request) GetHello() *HelloRequest {
+	if x, ok := x.GetBody().(*Request_Hello); ok {
+		return x.Hello
+	}
+	return nil
+}
+
+func (x *Request) GetDatagramBind() *DatagramBindRequest {
+	if x, ok := x.GetBody().(*Request_DatagramBind); ok {
+		return x.DatagramBind
+	}
+	return nil
+}
+
+type isRequest_Body interface {
+	isRequest_Body()
+}
+
+type Request_Info struct {
+	Info *InfoRequest `protobuf:"bytes,1,opt,name=info,proto3,oneof"`
+}
+
+type Request_Login struct {
+	Login *LoginRequest `protobuf:"bytes,2,opt,name=login,proto3,oneof"`
+}
+
+type Request_Logout struct {
+	Logout *LogoutRequest `protobuf:"bytes,3,opt,name=logout,proto3,oneof"`
+}
+
+type Request_GenerateRandomNickname struct {
+	GenerateRandomNickname *GenerateRandomNicknameRequest `protobuf:"bytes,4,opt,name=generate_random_nickname,json=generateRandomNickname,proto3,oneof"`
+}
+
+type Request_Ping struct {
+	Ping *PingRequest `protobuf:"bytes,5,opt,name=ping,proto3,oneof"`
+}
+
+type Request_Negotiate struct {
+	Negotiate *NegotiateRequest `protobuf:"bytes,6,opt,name=negotiate,proto3,oneof"`
+}
+
+type Request_Hello struct {
+	Hello *HelloRequest `protobuf:"bytes,7,opt,name=hello,proto3,oneof"`
+}
+
+type Request_DatagramBind struct {
+	DatagramBind *DatagramBindRequest `protobuf:"bytes,8,opt,name=datagram_bind,json=datagramBind,proto3,oneof"`
+}
+
+func (*Request_Info) isRequest_Body() {}
+
+func (*Request_Login) isRequest_Body() {}
+
+func (*Request_Logout) isRequest_Body() {}
+
+func (*Request_GenerateRandomNickname) isRequest_Body() {}
+
+func (*Request_Ping) isRequest_Body() {}
+
+func (*Request_Negotiate) isRequest_Body() {}
+
+func (*Request_Hello) isRequest_Body() {}
+
+func (*Request_DatagramBind) isRequest_Body() {}
+
+// Message for conveying response status information
+type Status struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Code    int32  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`      // Status code
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"` // Descriptive message
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Status) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *Status) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Message for encapsulating different response types
+type Response struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Body:
+	//
+	//	*Response_Status
+	//	*Response_Info
+	//	*Response_Login
+	//	*Response_Logout
+	//	*Response_GenerateRandomNickname
+	//	*Response_LoginQueue
+	//	*Response_Pong
+	//	*Response_Negotiate
+	//	*Response_Hello
+	//	*Response_DatagramBind
+	Body isResponse_Body `protobuf_oneof:"body"`
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{19}
+}
+
+func (m *Response) GetBody() isResponse_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (x *Response) GetStatus() *Status {
+	if x, ok := x.GetBody().(*Response_Status); ok {
 		return x.Status
 	}
 	return nil
@@ -718,6 +1346,41 @@ func (x *Response) GetGenerateRandomNickname() *GenerateRandomNicknameResponse {
 	return nil
 }
 
+func (x *Response) GetLoginQueue() *LoginQueueStatus {
+	if x, ok := x.GetBody().(*Response_LoginQueue); ok {
+		return x.LoginQueue
+	}
+	return nil
+}
+
+func (x *Response) GetPong() *PongResponse {
+	if x, ok := x.GetBody().(*Response_Pong); ok {
+		return x.Pong
+	}
+	return nil
+}
+
+func (x *Response) GetNegotiate() *NegotiateResponse {
+	if x, ok := x.GetBody().(*Response_Negotiate); ok {
+		return x.Negotiate
+	}
+	return nil
+}
+
+func (x *Response) GetHello() *HelloResponse {
+	if x, ok := x.GetBody().(*Response_Hello); ok {
+		return x.Hello
+	}
+	return nil
+}
+
+func (x *Response) GetDatagramBind() *DatagramBindResponse {
+	if x, ok := x.GetBody().(*Response_DatagramBind); ok {
+		return x.DatagramBind
+	}
+	return nil
+}
+
 type isResponse_Body interface {
 	isResponse_Body()
 }
@@ -738,19 +1401,328 @@ type Response_Logout struct {
 	Logout *LogoutResponse `protobuf:"bytes,4,opt,name=logout,proto3,oneof"`
 }
 
-type Response_GenerateRandomNickname struct {
-	GenerateRandomNickname *GenerateRandomNicknameResponse `protobuf:"bytes,5,opt,name=generate_random_nickname,json=generateRandomNickname,proto3,oneof"`
+type Response_GenerateRandomNickname struct {
+	GenerateRandomNickname *GenerateRandomNicknameResponse `protobuf:"bytes,5,opt,name=generate_random_nickname,json=generateRandomNickname,proto3,oneof"`
+}
+
+type Response_LoginQueue struct {
+	LoginQueue *LoginQueueStatus `protobuf:"bytes,6,opt,name=login_queue,json=loginQueue,proto3,oneof"`
+}
+
+type Response_Pong struct {
+	Pong *PongResponse `protobuf:"bytes,7,opt,name=pong,proto3,oneof"`
+}
+
+type Response_Negotiate struct {
+	Negotiate *NegotiateResponse `protobuf:"bytes,8,opt,name=negotiate,proto3,oneof"`
+}
+
+type Response_Hello struct {
+	Hello *HelloResponse `protobuf:"bytes,9,opt,name=hello,proto3,oneof"`
+}
+
+type Response_DatagramBind struct {
+	DatagramBind *DatagramBindResponse `protobuf:"bytes,10,opt,name=datagram_bind,json=datagramBind,proto3,oneof"`
+}
+
+func (*Response_Status) isResponse_Body() {}
+
+func (*Response_Info) isResponse_Body() {}
+
+func (*Response_Login) isResponse_Body() {}
+
+func (*Response_Logout) isResponse_Body() {}
+
+func (*Response_GenerateRandomNickname) isResponse_Body() {}
+
+func (*Response_LoginQueue) isResponse_Body() {}
+
+func (*Response_Pong) isResponse_Body() {}
+
+func (*Response_Negotiate) isResponse_Body() {}
+
+func (*Response_Hello) isResponse_Body() {}
+
+func (*Response_DatagramBind) isResponse_Body() {}
+
+// Kicked event, pushed before the session is closed by server
+type KickedEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reason string `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"` // Reason why kicked off
+}
+
+func (x *KickedEvent) Reset() {
+	*x = KickedEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KickedEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KickedEvent) ProtoMessage() {}
+
+func (x *KickedEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KickedEvent.ProtoReflect.Descriptor instead.
+func (*KickedEvent) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *KickedEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Shutdown event, pushed when the server starts draining before shutdown
+type ShutdownEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Reason            string `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`                                                // Reason why shutdown
+	DrainTimeoutMs    int64  `protobuf:"varint,2,opt,name=drain_timeout_ms,json=drainTimeoutMs,proto3" json:"drain_timeout_ms,omitempty"`       // Duration before the session is closed
+	ReconnectAfterMs  int64  `protobuf:"varint,3,opt,name=reconnect_after_ms,json=reconnectAfterMs,proto3" json:"reconnect_after_ms,omitempty"` // Hint of the delay before reconnecting
+	ReconnectEndpoint string `protobuf:"bytes,4,opt,name=reconnect_endpoint,json=reconnectEndpoint,proto3" json:"reconnect_endpoint,omitempty"` // Hint of the endpoint to reconnect, empty for the same one
+}
+
+func (x *ShutdownEvent) Reset() {
+	*x = ShutdownEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ShutdownEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShutdownEvent) ProtoMessage() {}
+
+func (x *ShutdownEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShutdownEvent.ProtoReflect.Descriptor instead.
+func (*ShutdownEvent) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ShutdownEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ShutdownEvent) GetDrainTimeoutMs() int64 {
+	if x != nil {
+		return x.DrainTimeoutMs
+	}
+	return 0
+}
+
+func (x *ShutdownEvent) GetReconnectAfterMs() int64 {
+	if x != nil {
+		return x.ReconnectAfterMs
+	}
+	return 0
+}
+
+func (x *ShutdownEvent) GetReconnectEndpoint() string {
+	if x != nil {
+		return x.ReconnectEndpoint
+	}
+	return ""
+}
+
+// Message for encapsulating different unsolicited server events
+type Event struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Body:
+	//
+	//	*Event_Kicked
+	//	*Event_LoginQueue
+	//	*Event_Login
+	//	*Event_Shutdown
+	Body isEvent_Body `protobuf_oneof:"body"`
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{22}
+}
+
+func (m *Event) GetBody() isEvent_Body {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
+func (x *Event) GetKicked() *KickedEvent {
+	if x, ok := x.GetBody().(*Event_Kicked); ok {
+		return x.Kicked
+	}
+	return nil
+}
+
+func (x *Event) GetLoginQueue() *LoginQueueStatus {
+	if x, ok := x.GetBody().(*Event_LoginQueue); ok {
+		return x.LoginQueue
+	}
+	return nil
+}
+
+func (x *Event) GetLogin() *LoginResponse {
+	if x, ok := x.GetBody().(*Event_Login); ok {
+		return x.Login
+	}
+	return nil
+}
+
+func (x *Event) GetShutdown() *ShutdownEvent {
+	if x, ok := x.GetBody().(*Event_Shutdown); ok {
+		return x.Shutdown
+	}
+	return nil
+}
+
+type isEvent_Body interface {
+	isEvent_Body()
+}
+
+type Event_Kicked struct {
+	Kicked *KickedEvent `protobuf:"bytes,1,opt,name=kicked,proto3,oneof"`
+}
+
+type Event_LoginQueue struct {
+	LoginQueue *LoginQueueStatus `protobuf:"bytes,2,opt,name=login_queue,json=loginQueue,proto3,oneof"` // Position updated in the login queue
+}
+
+type Event_Login struct {
+	Login *LoginResponse `protobuf:"bytes,3,opt,name=login,proto3,oneof"` // Admitted from the login queue
+}
+
+type Event_Shutdown struct {
+	Shutdown *ShutdownEvent `protobuf:"bytes,4,opt,name=shutdown,proto3,oneof"`
+}
+
+func (*Event_Kicked) isEvent_Body() {}
+
+func (*Event_LoginQueue) isEvent_Body() {}
+
+func (*Event_Login) isEvent_Body() {}
+
+func (*Event_Shutdown) isEvent_Body() {}
+
+// Batch envelope carrying several requests or responses in one frame, whose
+// responses are batched in the same order.
+type Batch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Messages []*Message `protobuf:"bytes,1,rep,name=messages,proto3" json:"messages,omitempty"`
+}
+
+func (x *Batch) Reset() {
+	*x = Batch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Batch) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Response_Status) isResponse_Body() {}
-
-func (*Response_Info) isResponse_Body() {}
+func (*Batch) ProtoMessage() {}
 
-func (*Response_Login) isResponse_Body() {}
+func (x *Batch) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
 
-func (*Response_Logout) isResponse_Body() {}
+// Deprecated: Use Batch.ProtoReflect.Descriptor instead.
+func (*Batch) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{23}
+}
 
-func (*Response_GenerateRandomNickname) isResponse_Body() {}
+func (x *Batch) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
 
 // Message for encapsulating protocol message
 type Message struct {
@@ -763,13 +1735,18 @@ type Message struct {
 	//
 	//	*Message_Request
 	//	*Message_Response
+	//	*Message_Event
+	//	*Message_Batch
 	Body isMessage_Body `protobuf_oneof:"body"`
+	// Sequence ID set by client to correlate the response with the request,
+	// which is echoed back by server. 0 for unsolicited server messages.
+	Seq uint64 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
 }
 
 func (x *Message) Reset() {
 	*x = Message{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_main_proto_msgTypes[11]
+		mi := &file_main_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -782,7 +1759,7 @@ func (x *Message) String() string {
 func (*Message) ProtoMessage() {}
 
 func (x *Message) ProtoReflect() protoreflect.Message {
-	mi := &file_main_proto_msgTypes[11]
+	mi := &file_main_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -795,7 +1772,7 @@ func (x *Message) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Message.ProtoReflect.Descriptor instead.
 func (*Message) Descriptor() ([]byte, []int) {
-	return file_main_proto_rawDescGZIP(), []int{11}
+	return file_main_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *Message) GetType() MessageType {
@@ -826,6 +1803,27 @@ func (x *Message) GetResponse() *Response {
 	return nil
 }
 
+func (x *Message) GetEvent() *Event {
+	if x, ok := x.GetBody().(*Message_Event); ok {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Message) GetBatch() *Batch {
+	if x, ok := x.GetBody().(*Message_Batch); ok {
+		return x.Batch
+	}
+	return nil
+}
+
+func (x *Message) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
 type isMessage_Body interface {
 	isMessage_Body()
 }
@@ -838,10 +1836,95 @@ type Message_Response struct {
 	Response *Response `protobuf:"bytes,3,opt,name=response,proto3,oneof"`
 }
 
+type Message_Event struct {
+	Event *Event `protobuf:"bytes,5,opt,name=event,proto3,oneof"`
+}
+
+type Message_Batch struct {
+	Batch *Batch `protobuf:"bytes,6,opt,name=batch,proto3,oneof"`
+}
+
 func (*Message_Request) isMessage_Body() {}
 
 func (*Message_Response) isMessage_Body() {}
 
+func (*Message_Event) isMessage_Body() {}
+
+func (*Message_Batch) isMessage_Body() {}
+
+// Datagram carried by a single UDP packet over the unreliable datagram channel,
+// which may be lost, duplicated or reordered.
+type Datagram struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Token   []byte `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`     // Session token bound by DATAGRAM_BIND, empty if sent by server
+	Seq     uint64 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`        // Sequence number increased per datagram, stale ones are dropped by receiver
+	Message []byte `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // Message encoded in protobuf, empty for the probe to learn client address
+	Mac     []byte `protobuf:"bytes,4,opt,name=mac,proto3" json:"mac,omitempty"`         // HMAC-SHA256 of the direction, seq and message with the key bound by DATAGRAM_BIND
+}
+
+func (x *Datagram) Reset() {
+	*x = Datagram{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_main_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Datagram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Datagram) ProtoMessage() {}
+
+func (x *Datagram) ProtoReflect() protoreflect.Message {
+	mi := &file_main_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Datagram.ProtoReflect.Descriptor instead.
+func (*Datagram) Descriptor() ([]byte, []int) {
+	return file_main_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *Datagram) GetToken() []byte {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *Datagram) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Datagram) GetMessage() []byte {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *Datagram) GetMac() []byte {
+	if x != nil {
+		return x.Mac
+	}
+	return nil
+}
+
 var File_main_proto protoreflect.FileDescriptor
 
 var file_main_proto_rawDesc = []byte{
@@ -863,95 +1946,225 @@ var file_main_proto_rawDesc = []byte{
 	0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x09,
 	0xba, 0x48, 0x06, 0x72, 0x04, 0x10, 0x01, 0x18, 0x20, 0x52, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77,
 	0x6f, 0x72, 0x64, 0x22, 0x0f, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x0f, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x10, 0x0a, 0x0e, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x0d, 0x0a, 0x0b, 0x49, 0x6e, 0x66, 0x6f, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x92, 0x02, 0x0a, 0x0c, 0x49, 0x6e, 0x66, 0x6f, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x76, 0x65,
-	0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x65,
-	0x72, 0x76, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x70, 0x74, 0x69,
-	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x70, 0x74, 0x69, 0x6d, 0x65,
-	0x12, 0x25, 0x0a, 0x0e, 0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x5f, 0x70, 0x6c, 0x61, 0x79, 0x65,
-	0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65,
-	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x74, 0x6f, 0x74, 0x61, 0x6c,
-	0x5f, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x39, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x18,
-	0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x49, 0x6e, 0x66,
-	0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63,
-	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x1a,
-	0x3a, 0x0a, 0x0c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
-	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
-	0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x4b, 0x0a, 0x1d, 0x47,
-	0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x4e, 0x69, 0x63,
-	0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03,
-	0x73, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x73, 0x65, 0x78, 0x12, 0x18,
-	0x0a, 0x07, 0x63, 0x75, 0x6c, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x07, 0x63, 0x75, 0x6c, 0x74, 0x75, 0x72, 0x65, 0x22, 0x3c, 0x0a, 0x1e, 0x47, 0x65, 0x6e, 0x65,
-	0x72, 0x61, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x4e, 0x69, 0x63, 0x6b, 0x6e, 0x61,
-	0x6d, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6e, 0x69,
-	0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6e, 0x69,
-	0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0xf6, 0x01, 0x0a, 0x07, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x27, 0x0a, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x11, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x12, 0x2a, 0x0a, 0x05, 0x6c,
-	0x6f, 0x67, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6d, 0x61, 0x69,
-	0x6e, 0x2e, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00,
-	0x52, 0x05, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x12, 0x2d, 0x0a, 0x06, 0x6c, 0x6f, 0x67, 0x6f, 0x75,
-	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4c,
-	0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x06,
-	0x6c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x12, 0x5f, 0x0a, 0x18, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61,
-	0x74, 0x65, 0x5f, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x5f, 0x6e, 0x69, 0x63, 0x6b, 0x6e, 0x61,
-	0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e,
+	0x6f, 0x6e, 0x73, 0x65, 0x22, 0x4f, 0x0a, 0x10, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x51, 0x75, 0x65,
+	0x75, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6f, 0x73, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x6f, 0x73, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x74, 0x61, 0x5f, 0x73, 0x65, 0x63, 0x6f,
+	0x6e, 0x64, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x65, 0x74, 0x61, 0x53, 0x65,
+	0x63, 0x6f, 0x6e, 0x64, 0x73, 0x22, 0x0f, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x10, 0x0a, 0x0e, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x0d, 0x0a, 0x0b, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x92, 0x02, 0x0a, 0x0c, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x75, 0x70, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x70, 0x74, 0x69, 0x6d,
+	0x65, 0x12, 0x25, 0x0a, 0x0e, 0x6f, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x5f, 0x70, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x6f, 0x6e, 0x6c, 0x69, 0x6e,
+	0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x5f, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x39, 0x0a, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
+	0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x49, 0x6e,
+	0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73,
+	0x1a, 0x3a, 0x0a, 0x0c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x4b, 0x0a, 0x1d,
 	0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x4e, 0x69,
-	0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52,
-	0x16, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x4e,
-	0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x22,
-	0x36, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a,
-	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
-	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0xa3, 0x02, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x53, 0x74, 0x61, 0x74,
-	0x75, 0x73, 0x48, 0x00, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x28, 0x0a, 0x04,
-	0x69, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6d, 0x61, 0x69,
-	0x6e, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00,
-	0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x12, 0x2b, 0x0a, 0x05, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4c, 0x6f, 0x67,
-	0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x05, 0x6c, 0x6f,
-	0x67, 0x69, 0x6e, 0x12, 0x2e, 0x0a, 0x06, 0x6c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x18, 0x04, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4c, 0x6f, 0x67, 0x6f, 0x75,
-	0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x06, 0x6c, 0x6f, 0x67,
-	0x6f, 0x75, 0x74, 0x12, 0x60, 0x0a, 0x18, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x5f,
-	0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x5f, 0x6e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x18,
-	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x47, 0x65, 0x6e,
+	0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x73, 0x65, 0x78, 0x12,
+	0x18, 0x0a, 0x07, 0x63, 0x75, 0x6c, 0x74, 0x75, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x07, 0x63, 0x75, 0x6c, 0x74, 0x75, 0x72, 0x65, 0x22, 0x3c, 0x0a, 0x1e, 0x47, 0x65, 0x6e,
 	0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x4e, 0x69, 0x63, 0x6b, 0x6e,
-	0x61, 0x6d, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x16, 0x67,
-	0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x4e, 0x69, 0x63,
-	0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x22, 0x91, 0x01,
-	0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x25, 0x0a, 0x04, 0x74, 0x79, 0x70,
-	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4d,
-	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65,
-	0x12, 0x29, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x0d, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x48, 0x00, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x08, 0x72,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e,
-	0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52,
-	0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64,
-	0x79, 0x2a, 0x4c, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65,
-	0x12, 0x08, 0x0a, 0x04, 0x49, 0x4e, 0x46, 0x4f, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x4c, 0x4f,
-	0x47, 0x49, 0x4e, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x4c, 0x4f, 0x47, 0x4f, 0x55, 0x54, 0x10,
-	0x02, 0x12, 0x1c, 0x0a, 0x18, 0x47, 0x45, 0x4e, 0x45, 0x52, 0x41, 0x54, 0x45, 0x5f, 0x52, 0x41,
-	0x4e, 0x44, 0x4f, 0x4d, 0x5f, 0x4e, 0x49, 0x43, 0x4b, 0x4e, 0x41, 0x4d, 0x45, 0x10, 0x03, 0x42,
-	0x70, 0x0a, 0x08, 0x63, 0x6f, 0x6d, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x42, 0x09, 0x4d, 0x61, 0x69,
-	0x6e, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x50, 0x01, 0x5a, 0x29, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
-	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x77, 0x61, 0x6e, 0x6c, 0x69, 0x71, 0x75, 0x6e, 0x2f, 0x63, 0x67,
-	0x6f, 0x2d, 0x67, 0x61, 0x6d, 0x65, 0x2d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0xa2, 0x02, 0x03, 0x4d, 0x58, 0x58, 0xaa, 0x02, 0x04, 0x4d, 0x61, 0x69, 0x6e,
-	0xca, 0x02, 0x04, 0x4d, 0x61, 0x69, 0x6e, 0xe2, 0x02, 0x10, 0x4d, 0x61, 0x69, 0x6e, 0x5c, 0x47,
-	0x50, 0x42, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x04, 0x4d, 0x61, 0x69,
-	0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x61, 0x6d, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6e,
+	0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6e,
+	0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x2b, 0x0a, 0x0b, 0x50, 0x69, 0x6e, 0x67, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x22, 0x6c, 0x0a, 0x0c, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x12, 0x1f, 0x0a, 0x0b, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x6d,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61,
+	0x6c, 0x4d, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6d,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x4d, 0x73, 0x22, 0xa0, 0x01, 0x0a, 0x0c, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x5f,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x25,
+	0x0a, 0x0e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x56, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72,
+	0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72,
+	0x6d, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x22, 0x5e, 0x0a, 0x0d, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x10, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x12, 0x22, 0x0a, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x69, 0x65,
+	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0c, 0x63, 0x61, 0x70, 0x61, 0x62, 0x69, 0x6c,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x22, 0x49, 0x0a, 0x10, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61,
+	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x35, 0x0a, 0x0c, 0x63, 0x6f, 0x6d,
+	0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0e, 0x32,
+	0x11, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x52, 0x0c, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x22, 0x66, 0x0a, 0x11, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x33, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e, 0x6d, 0x61, 0x69,
+	0x6e, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x63,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x74, 0x68,
+	0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x74,
+	0x68, 0x72, 0x65, 0x73, 0x68, 0x6f, 0x6c, 0x64, 0x22, 0x15, 0x0a, 0x13, 0x44, 0x61, 0x74, 0x61,
+	0x67, 0x72, 0x61, 0x6d, 0x42, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
+	0x5a, 0x0a, 0x14, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d, 0x42, 0x69, 0x6e, 0x64, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1a, 0x0a,
+	0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0xc5, 0x03, 0x0a, 0x07,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f,
+	0x12, 0x2a, 0x0a, 0x05, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x12, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x05, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x12, 0x2d, 0x0a, 0x06,
+	0x6c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6d,
+	0x61, 0x69, 0x6e, 0x2e, 0x4c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x48, 0x00, 0x52, 0x06, 0x6c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x12, 0x5f, 0x0a, 0x18, 0x67,
+	0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x5f, 0x6e,
+	0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e,
+	0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x61, 0x6e,
+	0x64, 0x6f, 0x6d, 0x4e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x48, 0x00, 0x52, 0x16, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x61,
+	0x6e, 0x64, 0x6f, 0x6d, 0x4e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x27, 0x0a, 0x04,
+	0x70, 0x69, 0x6e, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x6d, 0x61, 0x69,
+	0x6e, 0x2e, 0x50, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52,
+	0x04, 0x70, 0x69, 0x6e, 0x67, 0x12, 0x36, 0x0a, 0x09, 0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61,
+	0x74, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e,
+	0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x48, 0x00, 0x52, 0x09, 0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x12, 0x2a, 0x0a,
+	0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6d,
+	0x61, 0x69, 0x6e, 0x2e, 0x48, 0x65, 0x6c, 0x6c, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x48, 0x00, 0x52, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f, 0x12, 0x40, 0x0a, 0x0d, 0x64, 0x61, 0x74,
+	0x61, 0x67, 0x72, 0x61, 0x6d, 0x5f, 0x62, 0x69, 0x6e, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d,
+	0x42, 0x69, 0x6e, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x0c, 0x64,
+	0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d, 0x42, 0x69, 0x6e, 0x64, 0x42, 0x06, 0x0a, 0x04, 0x62,
+	0x6f, 0x64, 0x79, 0x22, 0x36, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x12, 0x0a,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64,
+	0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0xb1, 0x04, 0x0a, 0x08,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74,
+	0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0c, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x48, 0x00, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x28, 0x0a, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
+	0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x48, 0x00, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x12, 0x2b, 0x0a, 0x05, 0x6c, 0x6f,
+	0x67, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6d, 0x61, 0x69, 0x6e,
+	0x2e, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00,
+	0x52, 0x05, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x12, 0x2e, 0x0a, 0x06, 0x6c, 0x6f, 0x67, 0x6f, 0x75,
+	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4c,
+	0x6f, 0x67, 0x6f, 0x75, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52,
+	0x06, 0x6c, 0x6f, 0x67, 0x6f, 0x75, 0x74, 0x12, 0x60, 0x0a, 0x18, 0x67, 0x65, 0x6e, 0x65, 0x72,
+	0x61, 0x74, 0x65, 0x5f, 0x72, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x5f, 0x6e, 0x69, 0x63, 0x6b, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x6d, 0x61, 0x69, 0x6e,
+	0x2e, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x64, 0x6f, 0x6d, 0x4e,
+	0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48,
+	0x00, 0x52, 0x16, 0x67, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x65, 0x52, 0x61, 0x6e, 0x64, 0x6f,
+	0x6d, 0x4e, 0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x39, 0x0a, 0x0b, 0x6c, 0x6f, 0x67,
+	0x69, 0x6e, 0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16,
+	0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x51, 0x75, 0x65, 0x75, 0x65,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x48, 0x00, 0x52, 0x0a, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x51,
+	0x75, 0x65, 0x75, 0x65, 0x12, 0x28, 0x0a, 0x04, 0x70, 0x6f, 0x6e, 0x67, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x50, 0x6f, 0x6e, 0x67, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x04, 0x70, 0x6f, 0x6e, 0x67, 0x12, 0x37,
+	0x0a, 0x09, 0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61,
+	0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x09, 0x6e, 0x65,
+	0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x68, 0x65, 0x6c, 0x6c, 0x6f,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x48, 0x65,
+	0x6c, 0x6c, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x05, 0x68,
+	0x65, 0x6c, 0x6c, 0x6f, 0x12, 0x41, 0x0a, 0x0d, 0x64, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d,
+	0x5f, 0x62, 0x69, 0x6e, 0x64, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x6d, 0x61,
+	0x69, 0x6e, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61, 0x6d, 0x42, 0x69, 0x6e, 0x64, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x0c, 0x64, 0x61, 0x74, 0x61, 0x67,
+	0x72, 0x61, 0x6d, 0x42, 0x69, 0x6e, 0x64, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64, 0x79, 0x22,
+	0x25, 0x0a, 0x0b, 0x4b, 0x69, 0x63, 0x6b, 0x65, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0xae, 0x01, 0x0a, 0x0d, 0x53, 0x68, 0x75, 0x74, 0x64,
+	0x6f, 0x77, 0x6e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e,
+	0x12, 0x28, 0x0a, 0x10, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75,
+	0x74, 0x5f, 0x6d, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x64, 0x72, 0x61, 0x69,
+	0x6e, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4d, 0x73, 0x12, 0x2c, 0x0a, 0x12, 0x72, 0x65,
+	0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x5f, 0x6d, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x4d, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x72, 0x65, 0x63, 0x6f,
+	0x6e, 0x6e, 0x65, 0x63, 0x74, 0x5f, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x11, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x45,
+	0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x22, 0xd7, 0x01, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x12, 0x2b, 0x0a, 0x06, 0x6b, 0x69, 0x63, 0x6b, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x11, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4b, 0x69, 0x63, 0x6b, 0x65, 0x64, 0x45,
+	0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x06, 0x6b, 0x69, 0x63, 0x6b, 0x65, 0x64, 0x12, 0x39,
+	0x0a, 0x0b, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x5f, 0x71, 0x75, 0x65, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4c, 0x6f, 0x67, 0x69, 0x6e,
+	0x51, 0x75, 0x65, 0x75, 0x65, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x48, 0x00, 0x52, 0x0a, 0x6c,
+	0x6f, 0x67, 0x69, 0x6e, 0x51, 0x75, 0x65, 0x75, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x6c, 0x6f, 0x67,
+	0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e,
+	0x4c, 0x6f, 0x67, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52,
+	0x05, 0x6c, 0x6f, 0x67, 0x69, 0x6e, 0x12, 0x31, 0x0a, 0x08, 0x73, 0x68, 0x75, 0x74, 0x64, 0x6f,
+	0x77, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e,
+	0x53, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52,
+	0x08, 0x73, 0x68, 0x75, 0x74, 0x64, 0x6f, 0x77, 0x6e, 0x42, 0x06, 0x0a, 0x04, 0x62, 0x6f, 0x64,
+	0x79, 0x22, 0x32, 0x0a, 0x05, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x29, 0x0a, 0x08, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x6d,
+	0x61, 0x69, 0x6e, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x08, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x73, 0x22, 0xed, 0x01, 0x0a, 0x07, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x12, 0x25, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x11, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79,
+	0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x29, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x6d, 0x61, 0x69, 0x6e,
+	0x2e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x48, 0x00, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x23, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52,
+	0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x05, 0x62, 0x61, 0x74, 0x63, 0x68, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x2e, 0x42, 0x61, 0x74,
+	0x63, 0x68, 0x48, 0x00, 0x52, 0x05, 0x62, 0x61, 0x74, 0x63, 0x68, 0x12, 0x10, 0x0a, 0x03, 0x73,
+	0x65, 0x71, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x42, 0x06, 0x0a,
+	0x04, 0x62, 0x6f, 0x64, 0x79, 0x22, 0x5e, 0x0a, 0x08, 0x44, 0x61, 0x74, 0x61, 0x67, 0x72, 0x61,
+	0x6d, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x65, 0x71, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x61, 0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x03, 0x6d, 0x61, 0x63, 0x2a, 0xa3, 0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x49, 0x4e, 0x46, 0x4f, 0x10, 0x00, 0x12,
+	0x09, 0x0a, 0x05, 0x4c, 0x4f, 0x47, 0x49, 0x4e, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x4c, 0x4f,
+	0x47, 0x4f, 0x55, 0x54, 0x10, 0x02, 0x12, 0x1c, 0x0a, 0x18, 0x47, 0x45, 0x4e, 0x45, 0x52, 0x41,
+	0x54, 0x45, 0x5f, 0x52, 0x41, 0x4e, 0x44, 0x4f, 0x4d, 0x5f, 0x4e, 0x49, 0x43, 0x4b, 0x4e, 0x41,
+	0x4d, 0x45, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x56, 0x45, 0x4e, 0x54, 0x10, 0x04, 0x12,
+	0x08, 0x0a, 0x04, 0x50, 0x49, 0x4e, 0x47, 0x10, 0x05, 0x12, 0x08, 0x0a, 0x04, 0x50, 0x4f, 0x4e,
+	0x47, 0x10, 0x06, 0x12, 0x0d, 0x0a, 0x09, 0x4e, 0x45, 0x47, 0x4f, 0x54, 0x49, 0x41, 0x54, 0x45,
+	0x10, 0x07, 0x12, 0x09, 0x0a, 0x05, 0x48, 0x45, 0x4c, 0x4c, 0x4f, 0x10, 0x08, 0x12, 0x09, 0x0a,
+	0x05, 0x42, 0x41, 0x54, 0x43, 0x48, 0x10, 0x09, 0x12, 0x11, 0x0a, 0x0d, 0x44, 0x41, 0x54, 0x41,
+	0x47, 0x52, 0x41, 0x4d, 0x5f, 0x42, 0x49, 0x4e, 0x44, 0x10, 0x0a, 0x2a, 0x2d, 0x0a, 0x0b, 0x43,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x08, 0x0a, 0x04, 0x4e, 0x4f,
+	0x4e, 0x45, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x4e, 0x41, 0x50, 0x50, 0x59, 0x10, 0x01,
+	0x12, 0x08, 0x0a, 0x04, 0x5a, 0x53, 0x54, 0x44, 0x10, 0x02, 0x42, 0x70, 0x0a, 0x08, 0x63, 0x6f,
+	0x6d, 0x2e, 0x6d, 0x61, 0x69, 0x6e, 0x42, 0x09, 0x4d, 0x61, 0x69, 0x6e, 0x50, 0x72, 0x6f, 0x74,
+	0x6f, 0x50, 0x01, 0x5a, 0x29, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x77, 0x61, 0x6e, 0x6c, 0x69, 0x71, 0x75, 0x6e, 0x2f, 0x63, 0x67, 0x6f, 0x2d, 0x67, 0x61, 0x6d,
+	0x65, 0x2d, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0xa2, 0x02,
+	0x03, 0x4d, 0x58, 0x58, 0xaa, 0x02, 0x04, 0x4d, 0x61, 0x69, 0x6e, 0xca, 0x02, 0x04, 0x4d, 0x61,
+	0x69, 0x6e, 0xe2, 0x02, 0x10, 0x4d, 0x61, 0x69, 0x6e, 0x5c, 0x47, 0x50, 0x42, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0xea, 0x02, 0x04, 0x4d, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -966,43 +2179,76 @@ func file_main_proto_rawDescGZIP() []byte {
 	return file_main_proto_rawDescData
 }
 
-var file_main_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_main_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_main_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_main_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_main_proto_goTypes = []interface{}{
 	(MessageType)(0),                       // 0: main.MessageType
-	(*LoginRequest)(nil),                   // 1: main.LoginRequest
-	(*LoginResponse)(nil),                  // 2: main.LoginResponse
-	(*LogoutRequest)(nil),                  // 3: main.LogoutRequest
-	(*LogoutResponse)(nil),                 // 4: main.LogoutResponse
-	(*InfoRequest)(nil),                    // 5: main.InfoRequest
-	(*InfoResponse)(nil),                   // 6: main.InfoResponse
-	(*GenerateRandomNicknameRequest)(nil),  // 7: main.GenerateRandomNicknameRequest
-	(*GenerateRandomNicknameResponse)(nil), // 8: main.GenerateRandomNicknameResponse
-	(*Request)(nil),                        // 9: main.Request
-	(*Status)(nil),                         // 10: main.Status
-	(*Response)(nil),                       // 11: main.Response
-	(*Message)(nil),                        // 12: main.Message
-	nil,                                    // 13: main.InfoResponse.MetricsEntry
+	(Compression)(0),                       // 1: main.Compression
+	(*LoginRequest)(nil),                   // 2: main.LoginRequest
+	(*LoginResponse)(nil),                  // 3: main.LoginResponse
+	(*LoginQueueStatus)(nil),               // 4: main.LoginQueueStatus
+	(*LogoutRequest)(nil),                  // 5: main.LogoutRequest
+	(*LogoutResponse)(nil),                 // 6: main.LogoutResponse
+	(*InfoRequest)(nil),                    // 7: main.InfoRequest
+	(*InfoResponse)(nil),                   // 8: main.InfoResponse
+	(*GenerateRandomNicknameRequest)(nil),  // 9: main.GenerateRandomNicknameRequest
+	(*GenerateRandomNicknameResponse)(nil), // 10: main.GenerateRandomNicknameResponse
+	(*PingRequest)(nil),                    // 11: main.PingRequest
+	(*PongResponse)(nil),                   // 12: main.PongResponse
+	(*HelloRequest)(nil),                   // 13: main.HelloRequest
+	(*HelloResponse)(nil),                  // 14: main.HelloResponse
+	(*NegotiateRequest)(nil),               // 15: main.NegotiateRequest
+	(*NegotiateResponse)(nil),              // 16: main.NegotiateResponse
+	(*DatagramBindRequest)(nil),            // 17: main.DatagramBindRequest
+	(*DatagramBindResponse)(nil),           // 18: main.DatagramBindResponse
+	(*Request)(nil),                        // 19: main.Request
+	(*Status)(nil),                         // 20: main.Status
+	(*Response)(nil),                       // 21: main.Response
+	(*KickedEvent)(nil),                    // 22: main.KickedEvent
+	(*ShutdownEvent)(nil),                  // 23: main.ShutdownEvent
+	(*Event)(nil),                          // 24: main.Event
+	(*Batch)(nil),                          // 25: main.Batch
+	(*Message)(nil),                        // 26: main.Message
+	(*Datagram)(nil),                       // 27: main.Datagram
+	nil,                                    // 28: main.InfoResponse.MetricsEntry
 }
 var file_main_proto_depIdxs = []int32{
-	13, // 0: main.InfoResponse.metrics:type_name -> main.InfoResponse.MetricsEntry
-	5,  // 1: main.Request.info:type_name -> main.InfoRequest
-	1,  // 2: main.Request.login:type_name -> main.LoginRequest
-	3,  // 3: main.Request.logout:type_name -> main.LogoutRequest
-	7,  // 4: main.Request.generate_random_nickname:type_name -> main.GenerateRandomNicknameRequest
-	10, // 5: main.Response.status:type_name -> main.Status
-	6,  // 6: main.Response.info:type_name -> main.InfoResponse
-	2,  // 7: main.Response.login:type_name -> main.LoginResponse
-	4,  // 8: main.Response.logout:type_name -> main.LogoutResponse
-	8,  // 9: main.Response.generate_random_nickname:type_name -> main.GenerateRandomNicknameResponse
-	0,  // 10: main.Message.type:type_name -> main.MessageType
-	9,  // 11: main.Message.request:type_name -> main.Request
-	11, // 12: main.Message.response:type_name -> main.Response
-	13, // [13:13] is the sub-list for method output_type
-	13, // [13:13] is the sub-list for method input_type
-	13, // [13:13] is the sub-list for extension type_name
-	13, // [13:13] is the sub-list for extension extendee
-	0,  // [0:13] is the sub-list for field type_name
+	28, // 0: main.InfoResponse.metrics:type_name -> main.InfoResponse.MetricsEntry
+	1,  // 1: main.NegotiateRequest.compressions:type_name -> main.Compression
+	1,  // 2: main.NegotiateResponse.compression:type_name -> main.Compression
+	7,  // 3: main.Request.info:type_name -> main.InfoRequest
+	2,  // 4: main.Request.login:type_name -> main.LoginRequest
+	5,  // 5: main.Request.logout:type_name -> main.LogoutRequest
+	9,  // 6: main.Request.generate_random_nickname:type_name -> main.GenerateRandomNicknameRequest
+	11, // 7: main.Request.ping:type_name -> main.PingRequest
+	15, // 8: main.Request.negotiate:type_name -> main.NegotiateRequest
+	13, // 9: main.Request.hello:type_name -> main.HelloRequest
+	17, // 10: main.Request.datagram_bind:type_name -> main.DatagramBindRequest
+	20, // 11: main.Response.status:type_name -> main.Status
+	8,  // 12: main.Response.info:type_name -> main.InfoResponse
+	3,  // 13: main.Response.login:type_name -> main.LoginResponse
+	6,  // 14: main.Response.logout:type_name -> main.LogoutResponse
+	10, // 15: main.Response.generate_random_nickname:type_name -> main.GenerateRandomNicknameResponse
+	4,  // 16: main.Response.login_queue:type_name -> main.LoginQueueStatus
+	12, // 17: main.Response.pong:type_name -> main.PongResponse
+	16, // 18: main.Response.negotiate:type_name -> main.NegotiateResponse
+	14, // 19: main.Response.hello:type_name -> main.HelloResponse
+	18, // 20: main.Response.datagram_bind:type_name -> main.DatagramBindResponse
+	22, // 21: main.Event.kicked:type_name -> main.KickedEvent
+	4,  // 22: main.Event.login_queue:type_name -> main.LoginQueueStatus
+	3,  // 23: main.Event.login:type_name -> main.LoginResponse
+	23, // 24: main.Event.shutdown:type_name -> main.ShutdownEvent
+	26, // 25: main.Batch.messages:type_name -> main.Message
+	0,  // 26: main.Message.type:type_name -> main.MessageType
+	19, // 27: main.Message.request:type_name -> main.Request
+	21, // 28: main.Message.response:type_name -> main.Response
+	24, // 29: main.Message.event:type_name -> main.Event
+	25, // 30: main.Message.batch:type_name -> main.Batch
+	31, // [31:31] is the sub-list for method output_type
+	31, // [31:31] is the sub-list for method input_type
+	31, // [31:31] is the sub-list for extension type_name
+	31, // [31:31] is the sub-list for extension extendee
+	0,  // [0:31] is the sub-list for field type_name
 }
 
 func init() { file_main_proto_init() }
@@ -1036,7 +2282,7 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LogoutRequest); i {
+			switch v := v.(*LoginQueueStatus); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1048,7 +2294,7 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LogoutResponse); i {
+			switch v := v.(*LogoutRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1060,7 +2306,7 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*InfoRequest); i {
+			switch v := v.(*LogoutResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1072,7 +2318,7 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*InfoResponse); i {
+			switch v := v.(*InfoRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1084,7 +2330,7 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GenerateRandomNicknameRequest); i {
+			switch v := v.(*InfoResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1096,7 +2342,7 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GenerateRandomNicknameResponse); i {
+			switch v := v.(*GenerateRandomNicknameRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1108,7 +2354,7 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Request); i {
+			switch v := v.(*GenerateRandomNicknameResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1120,7 +2366,7 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Status); i {
+			switch v := v.(*PingRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1132,7 +2378,7 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Response); i {
+			switch v := v.(*PongResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1144,6 +2390,162 @@ func file_main_proto_init() {
 			}
 		}
 		file_main_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HelloRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HelloResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NegotiateRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NegotiateResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatagramBindRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DatagramBindResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Request); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Status); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Response); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*KickedEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ShutdownEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Event); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Batch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_main_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Message); i {
 			case 0:
 				return &v.state
@@ -1155,31 +2557,60 @@ func file_main_proto_init() {
 				return nil
 			}
 		}
+		file_main_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Datagram); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
-	file_main_proto_msgTypes[8].OneofWrappers = []interface{}{
+	file_main_proto_msgTypes[17].OneofWrappers = []interface{}{
 		(*Request_Info)(nil),
 		(*Request_Login)(nil),
 		(*Request_Logout)(nil),
 		(*Request_GenerateRandomNickname)(nil),
+		(*Request_Ping)(nil),
+		(*Request_Negotiate)(nil),
+		(*Request_Hello)(nil),
+		(*Request_DatagramBind)(nil),
 	}
-	file_main_proto_msgTypes[10].OneofWrappers = []interface{}{
+	file_main_proto_msgTypes[19].OneofWrappers = []interface{}{
 		(*Response_Status)(nil),
 		(*Response_Info)(nil),
 		(*Response_Login)(nil),
 		(*Response_Logout)(nil),
 		(*Response_GenerateRandomNickname)(nil),
+		(*Response_LoginQueue)(nil),
+		(*Response_Pong)(nil),
+		(*Response_Negotiate)(nil),
+		(*Response_Hello)(nil),
+		(*Response_DatagramBind)(nil),
+	}
+	file_main_proto_msgTypes[22].OneofWrappers = []interface{}{
+		(*Event_Kicked)(nil),
+		(*Event_LoginQueue)(nil),
+		(*Event_Login)(nil),
+		(*Event_Shutdown)(nil),
 	}
-	file_main_proto_msgTypes[11].OneofWrappers = []interface{}{
+	file_main_proto_msgTypes[24].OneofWrappers = []interface{}{
 		(*Message_Request)(nil),
 		(*Message_Response)(nil),
+		(*Message_Event)(nil),
+		(*Message_Batch)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_main_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   13,
+			NumEnums:      2,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -2,29 +2,67 @@ package rest
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/wanliqun/cgo-game-server/service"
 )
 
+const (
+	defaultSessionsLimit = "100"
+)
+
 type Controller struct {
 	axService *service.AuxiliaryService
 }
 
 type ServerStatus struct {
 	*service.ServerStatus
-	Uptime string
+	Uptime                string
+	LoginQueueAvgWaitTime string
+	LoginQueueMaxWaitTime string
+	DrainElapsed          string
 }
 
 func (c *Controller) Status(ctx *gin.Context) {
 	srvStat := c.axService.CollectServerStatus()
 	ctx.JSON(http.StatusOK, &ServerStatus{
-		ServerStatus: srvStat,
-		Uptime:       srvStat.Uptime.String(),
+		ServerStatus:          srvStat,
+		Uptime:                srvStat.Uptime.String(),
+		LoginQueueAvgWaitTime: srvStat.LoginQueueAvgWaitTime.String(),
+		LoginQueueMaxWaitTime: srvStat.LoginQueueMaxWaitTime.String(),
+		DrainElapsed:          srvStat.DrainElapsed.String(),
 	})
 }
 
 func (c *Controller) Metrics(ctx *gin.Context) {
 	metrics := c.axService.GatherAllRPCRateMetrics()
+	for k, v := range c.axService.GatherCompressionMetrics() {
+		metrics[k] = v
+	}
+	for k, v := range c.axService.GatherFrameViolationMetrics() {
+		metrics[k] = v
+	}
+	for k, v := range c.axService.GatherIPLimitMetrics() {
+		metrics[k] = v
+	}
+	for k, v := range c.axService.GatherSlowConsumerMetrics() {
+		metrics[k] = v
+	}
+	for k, v := range c.axService.GatherDatagramMetrics() {
+		metrics[k] = v
+	}
 	ctx.JSON(http.StatusOK, metrics)
 }
+
+// Sessions lists the outbound queue statistics of the sessions, slow consumers
+// first, which is limited by the `limit` query parameter.
+func (c *Controller) Sessions(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", defaultSessionsLimit))
+	if err != nil || limit <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.axService.CollectSessionStats(limit))
+}
@@ -21,7 +21,8 @@ func newRouter(svcFactory *service.Factory) *gin.Engine {
 	c := &Controller{axService: svcFactory.Auxiliary}
 	router.Group("/").
 		GET("status", c.Status).
-		GET("metrics", c.Metrics)
+		GET("metrics", c.Metrics).
+		GET("sessions", c.Sessions)
 
 	return router
 }
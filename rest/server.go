@@ -6,31 +6,41 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"github.com/wanliqun/cgo-game-server/service"
+	"github.com/wanliqun/cgo-game-server/util"
 )
 
 type Server struct {
 	*http.Server
 	listener net.Listener // Net listener
+	router   *gin.Engine
 }
 
 func NewServer(endpoint string, svcFactory *service.Factory) (*Server, error) {
-	ln, err := net.Listen("tcp", endpoint)
+	ln, err := util.ListenTCP(endpoint)
 	if err != nil {
 		return nil, err
 	}
 
+	router := newRouter(svcFactory)
 	return &Server{
 		listener: ln,
+		router:   router,
 		Server: &http.Server{
 			Addr:        endpoint,
 			ReadTimeout: 1 * time.Minute,
-			Handler:     newRouter(svcFactory),
+			Handler:     router,
 		},
 	}, nil
 }
 
+// Mount mounts the HTTP handler at the path for GET requests, eg., WebSocket upgrade.
+func (s *Server) Mount(path string, h http.Handler) {
+	s.router.GET(path, gin.WrapH(h))
+}
+
 func (s *Server) Serve() error {
 	logrus.WithFields(logrus.Fields{
 		"endpoint": s.listener.Addr(),
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+const (
+	// Max number of refused connections being responded concurrently, beyond which
+	// they are closed without response.
+	maxConcurrentRefusals = 128
+)
+
+var (
+	errServerFull = &StatusError{
+		Code: StatusServerFull,
+		Err:  errors.New("server full"),
+	}
+)
+
+// connCounter counts active connections against a capacity limit.
+type connCounter struct {
+	capacity int64        // Max capacity, non-positive means unlimited
+	active   atomic.Int64 // Active connections
+	rejected atomic.Int64 // Rejected connections
+}
+
+func (c *connCounter) acquire() bool {
+	if c.capacity <= 0 {
+		c.active.Add(1)
+		return true
+	}
+
+	if c.active.Add(1) > c.capacity {
+		c.active.Add(-1)
+		return false
+	}
+
+	return true
+}
+
+func (c *connCounter) release() {
+	c.active.Add(-1)
+}
+
+// AdmissionController refuses new connections once the global capacity or the
+// capacity of the specific transport (eg., "tcp", "udp") is reached.
+type AdmissionController struct {
+	global     connCounter
+	transports map[string]*connCounter // network => counter, read-only after construction
+	refusals   chan struct{}           // Semaphore of the refusals being responded
+}
+
+func NewAdmissionController(capacity int, transportCapacities map[string]int) *AdmissionController {
+	ac := &AdmissionController{
+		global:     connCounter{capacity: int64(capacity)},
+		transports: make(map[string]*connCounter),
+		refusals:   make(chan struct{}, maxConcurrentRefusals),
+	}
+
+	for network, c := range transportCapacities {
+		ac.transports[network] = &connCounter{capacity: int64(c)}
+	}
+
+	return ac
+}
+
+// Admit tries to admit a new connection for the network transport, and returns
+// an error if the server is full. An admitted connection must be released later.
+func (ac *AdmissionController) Admit(network string) error {
+	tc := ac.transports[network]
+	if tc != nil && !tc.acquire() {
+		tc.rejected.Add(1)
+		ac.global.rejected.Add(1)
+		return errServerFull
+	}
+
+	if !ac.global.acquire() {
+		if tc != nil {
+			tc.release()
+			tc.rejected.Add(1)
+		}
+
+		ac.global.rejected.Add(1)
+		return errServerFull
+	}
+
+	return nil
+}
+
+// Release releases an admitted connection for the network transport.
+func (ac *AdmissionController) Release(network string) {
+	if tc := ac.transports[network]; tc != nil {
+		tc.release()
+	}
+
+	ac.global.release()
+}
+
+// Count returns the number of admitted connections.
+func (ac *AdmissionController) Count() int {
+	return int(ac.global.active.Load())
+}
+
+// Rejected returns the total number of rejected connections.
+func (ac *AdmissionController) Rejected() int64 {
+	return ac.global.rejected.Load()
+}
+
+// RejectedByTransport returns the number of rejected connections for each
+// network transport with capacity limit.
+func (ac *AdmissionController) RejectedByTransport() map[string]int64 {
+	res := make(map[string]int64, len(ac.transports))
+	for network, tc := range ac.transports {
+		res[network] = tc.rejected.Load()
+	}
+
+	return res
+}
+
+// acquireRefusal tries to reserve a slot to respond the refused connection, and
+// returns false once too many refusals in progress, eg., under overload.
+func (ac *AdmissionController) acquireRefusal() bool {
+	select {
+	case ac.refusals <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (ac *AdmissionController) releaseRefusal() {
+	<-ac.refusals
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+func TestAdmissionController(t *testing.T) {
+	ac := NewAdmissionController(3, map[string]int{"tcp": 2})
+
+	// Transport capacity is reached first.
+	require.NoError(t, ac.Admit("tcp"))
+	require.NoError(t, ac.Admit("tcp"))
+	assert.ErrorIs(t, ac.Admit("tcp"), errServerFull)
+
+	// And then the global capacity.
+	require.NoError(t, ac.Admit("udp"))
+	assert.ErrorIs(t, ac.Admit("udp"), errServerFull)
+
+	assert.Equal(t, 3, ac.Count())
+	assert.EqualValues(t, 2, ac.Rejected())
+	assert.EqualValues(t, 1, ac.RejectedByTransport()["tcp"])
+
+	// Released capacity is admitted again.
+	ac.Release("tcp")
+	require.NoError(t, ac.Admit("tcp"))
+	assert.Equal(t, 3, ac.Count())
+}
+
+func TestServerRefuse(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.Admission = NewAdmissionController(1, nil)
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", srv.listener.Addr().String())
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+
+		conn.SetDeadline(time.Now().Add(3 * time.Second))
+		return conn
+	}
+
+	dial()
+	require.Eventually(t, func() bool {
+		return ch.Admission.Count() == 1
+	}, 3*time.Second, 10*time.Millisecond)
+
+	// Refused connection is responded with the server full status.
+	resp, err := proto.NewCodec().Decode(dial())
+	require.NoError(t, err)
+	assert.Equal(t, StatusServerFull, resp.GetResponse().GetStatus().GetCode())
+
+	// Closed without response once too many refusals in progress.
+	for i := 0; i < maxConcurrentRefusals; i++ {
+		require.True(t, ch.Admission.acquireRefusal())
+	}
+	defer func() {
+		for i := 0; i < maxConcurrentRefusals; i++ {
+			ch.Admission.releaseRefusal()
+		}
+	}()
+
+	_, err = dial().Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+const (
+	defaultBatchMaxSize = 64
+)
+
+// BatchOption configures the batch envelope carrying several messages in one frame.
+type BatchOption struct {
+	MaxSize int // Max number of messages per batch
+}
+
+func (o BatchOption) maxSize() int {
+	if o.MaxSize > 0 {
+		return o.MaxSize
+	}
+	return defaultBatchMaxSize
+}
+
+// isBatch checks if the message is a BATCH envelope, whose messages are fanned
+// out to be handled one by one.
+func isBatch(msg *proto.Message) bool {
+	return msg.Type == proto.MessageType_BATCH && msg.GetBatch() != nil
+}
+
+// serveBatch fans out the batched messages, and answers with the batch of the
+// responses in the same order. Batched messages are handled concurrently by at
+// most the in-flight limit of workers if pipeline enabled, unless any of them
+// should be handled in order.
+func (ch *ConnectionHandler) serveBatch(session *Session, msg *proto.Message) *proto.Message {
+	msgs := msg.GetBatch().GetMessages()
+	resps := make([]*proto.Message, len(msgs))
+
+	if len(msgs) > ch.Batch.maxSize() {
+		// Reject each of the batched messages, so that all of them are answered.
+		err := errors.Errorf("batch size %d exceeds limit %d", len(msgs), ch.Batch.maxSize())
+		for i := range msgs {
+			resps[i] = newBatchErrorResponse(msgs[i], err)
+		}
+
+		return proto.NewBatchMessage(resps...)
+	}
+
+	serve := func(i int) {
+		if isBatch(msgs[i]) {
+			resps[i] = newBatchErrorResponse(msgs[i], errors.New("nested batch not allowed"))
+			return
+		}
+
+		resps[i] = ch.serve(session, msgs[i])
+	}
+
+	if !ch.Pipeline.Enabled || ch.hasOrderedType(msgs) {
+		for i := range msgs {
+			serve(i)
+		}
+
+		return proto.NewBatchMessage(resps...)
+	}
+
+	indexes := make(chan int, len(msgs))
+	for i := range msgs {
+		indexes <- i
+	}
+	close(indexes)
+
+	var wg sync.WaitGroup
+	for w := min(len(msgs), ch.Pipeline.maxInFlight()); w > 0; w-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				serve(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return proto.NewBatchMessage(resps...)
+}
+
+// newBatchErrorResponse responds the batched message with bad request status,
+// which echoes the sequence ID of the message.
+func newBatchErrorResponse(msg *proto.Message, err error) *proto.Message {
+	resp := NewMessageWithError(NewBadRequestError(err)).ProtoMessage()
+	resp.Seq = msg.Seq
+	return resp
+}
+
+// isOrdered checks if the message should be handled in order, including the
+// batch carrying any of such messages.
+func (ch *ConnectionHandler) isOrdered(msg *proto.Message) bool {
+	if isBatch(msg) {
+		return ch.hasOrderedType(msg.GetBatch().GetMessages())
+	}
+	return ch.Pipeline.OrderedTypes[msg.Type]
+}
+
+func (ch *ConnectionHandler) hasOrderedType(msgs []*proto.Message) bool {
+	for _, msg := range msgs {
+		if ch.Pipeline.OrderedTypes[msg.Type] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/client"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+func TestBatch(t *testing.T) {
+	for _, pipelined := range []bool{false, true} {
+		ch := newInfoConnectionHandler()
+		ch.Pipeline.Enabled = pipelined
+
+		srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+		require.NoError(t, err)
+
+		go srv.Serve()
+		defer srv.Close()
+
+		conn, err := net.Dial("tcp", srv.listener.Addr().String())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+		info, _ := proto.NewRequestMessage(&proto.InfoRequest{})
+		info.Seq = 1
+		ping, _ := proto.NewRequestMessage(&proto.PingRequest{})
+		ping.Seq = 2
+		nested := proto.NewBatchMessage()
+		nested.Seq = 3
+
+		codec := proto.NewCodec()
+		require.NoError(t, codec.Encode(proto.NewBatchMessage(info, ping, nested), conn))
+
+		resp, err := codec.Decode(conn)
+		require.NoError(t, err)
+
+		msgs := resp.GetBatch().GetMessages()
+		require.Len(t, msgs, 3)
+
+		assert.EqualValues(t, 1, msgs[0].Seq)
+		assert.Equal(t, "test", msgs[0].GetResponse().GetInfo().GetServerName())
+		assert.EqualValues(t, 2, msgs[1].Seq)
+		assert.NotNil(t, msgs[1].GetResponse().GetPong())
+		assert.EqualValues(t, 3, msgs[2].Seq)
+		assert.Equal(t, StatusBadRequest, msgs[2].GetResponse().GetStatus().GetCode())
+	}
+}
+
+func TestBatchPipelinedInFlight(t *testing.T) {
+	var inflight, peak atomic.Int32
+
+	ch := newInfoConnectionHandler()
+	info := ch.Handler
+	ch.Handler = func(ctx context.Context, msg *Message) *Message {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+
+		for {
+			if p := peak.Load(); n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		return info(ctx, msg)
+	}
+	ch.Pipeline = PipelineOption{Enabled: true, MaxInFlight: 2}
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+	var msgs []*proto.Message
+	for i := 0; i < 8; i++ {
+		msg, _ := proto.NewRequestMessage(&proto.InfoRequest{})
+		msg.Seq = uint64(i + 1)
+		msgs = append(msgs, msg)
+	}
+
+	codec := proto.NewCodec()
+	require.NoError(t, codec.Encode(proto.NewBatchMessage(msgs...), conn))
+
+	resp, err := codec.Decode(conn)
+	require.NoError(t, err)
+
+	// Batched messages are handled concurrently within the in-flight limit.
+	resps := resp.GetBatch().GetMessages()
+	require.Len(t, resps, 8)
+	for i, r := range resps {
+		assert.EqualValues(t, i+1, r.Seq)
+	}
+	assert.EqualValues(t, 2, peak.Load())
+}
+
+func TestBatchOversize(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.Batch.MaxSize = 2
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	session := newBufferedSession(conn, ch.Codec, ch.Outbound)
+
+	var msgs []*proto.Message
+	for i := 1; i <= 3; i++ {
+		msg, _ := proto.NewRequestMessage(&proto.InfoRequest{})
+		msg.Seq = uint64(i)
+		msgs = append(msgs, msg)
+	}
+
+	// Each of the rejected messages is answered, so that no future left pending.
+	resps := ch.serveBatch(session, proto.NewBatchMessage(msgs...)).GetBatch().GetMessages()
+	require.Len(t, resps, 3)
+
+	for i, resp := range resps {
+		assert.EqualValues(t, i+1, resp.Seq)
+		assert.Equal(t, StatusBadRequest, resp.GetResponse().GetStatus().GetCode())
+	}
+}
+
+func TestBatchClientCoalescing(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.Handshake.Capabilities = []string{proto.CapabilityBatch}
+
+	codec := &batchCountingCodec{Codec: proto.NewCodec()}
+	ch.Codec = codec
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	c := client.NewTCPClient(srv.listener.Addr().String())
+	require.NoError(t, c.Connect())
+	defer c.Close()
+
+	require.Eventually(t, func() bool {
+		return c.HasCapability(proto.CapabilityBatch)
+	}, 3*time.Second, 10*time.Millisecond)
+
+	var futures []*client.Future
+	for i := 0; i < 50; i++ {
+		f, err := c.Go(&proto.InfoRequest{})
+		require.NoError(t, err)
+		futures = append(futures, f)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for _, f := range futures {
+		resp, err := f.Wait(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "test", resp.GetResponse().GetInfo().GetServerName())
+	}
+
+	assert.Greater(t, codec.batches.Load(), int32(0))
+}
+
+// batchCountingCodec counts the decoded batches.
+type batchCountingCodec struct {
+	*proto.Codec
+	batches atomic.Int32
+}
+
+func (c *batchCountingCodec) Decode(r io.Reader) (*proto.Message, error) {
+	msg, err := c.Codec.Decode(r)
+	if err == nil && msg.GetBatch() != nil {
+		c.batches.Add(1)
+	}
+
+	return msg, err
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+const (
+	defaultCompressionThreshold = 1024
+)
+
+// CompressionOption configures the per-frame compression negotiated by client.
+type CompressionOption struct {
+	Algorithms []proto.Compression // Supported compressions, none if empty
+	Threshold  int                 // Frames smaller than the threshold in bytes stay uncompressed
+}
+
+func (o CompressionOption) threshold() int {
+	if o.Threshold > 0 {
+		return o.Threshold
+	}
+	return defaultCompressionThreshold
+}
+
+func (o CompressionOption) supports(c proto.Compression) bool {
+	for _, v := range o.Algorithms {
+		if v == c {
+			return true
+		}
+	}
+	return false
+}
+
+// isNegotiation checks if the message is a NEGOTIATE request, which is handled
+// by the connection handler directly without going through the handler chain.
+func isNegotiation(msg *proto.Message) bool {
+	return msg.Type == proto.MessageType_NEGOTIATE && msg.GetRequest().GetNegotiate() != nil
+}
+
+// negotiate agrees on the first compression in client preference order supported
+// by server, and then compresses the outbound frames of the session. Only the
+// agreed compression is accepted for the inbound frames, whose decompressed size
+// is limited by the max frame size as well. Only the length-prefixed protobuf
+// codec supports compression.
+func (ch *ConnectionHandler) negotiate(session *Session, msg *proto.Message) *proto.Message {
+	agreed := proto.Compression_NONE
+	if codec, ok := ch.Codec.(*proto.Codec); ok {
+		for _, c := range msg.GetRequest().GetNegotiate().GetCompressions() {
+			if c != proto.Compression_NONE && ch.Compression.supports(c) {
+				agreed = c
+				break
+			}
+		}
+
+		codec = codec.WithCompression(agreed, ch.Compression.threshold())
+		codec.MaxSize = ch.Frame.MaxSize
+		session.SetCodec(codec)
+	}
+
+	resp, _ := proto.NewResponseMessage(&proto.NegotiateResponse{
+		Compression: agreed,
+		Threshold:   int32(ch.Compression.threshold()),
+	})
+	resp.Seq = msg.Seq
+
+	return resp
+}
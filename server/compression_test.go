@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/client"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+func TestCompressionNegotiation(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.Compression = CompressionOption{
+		Algorithms: []proto.Compression{proto.Compression_SNAPPY},
+		Threshold:  1,
+	}
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	c := client.NewTCPClient(
+		srv.listener.Addr().String(),
+		client.WithCompression(proto.Compression_ZSTD, proto.Compression_SNAPPY),
+	)
+	require.NoError(t, c.Connect())
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resp, err := c.Call(ctx, &proto.NegotiateRequest{
+		Compressions: []proto.Compression{proto.Compression_ZSTD, proto.Compression_SNAPPY},
+	})
+	require.NoError(t, err)
+
+	negotiated := resp.GetResponse().GetNegotiate()
+	assert.Equal(t, proto.Compression_SNAPPY, negotiated.GetCompression())
+	assert.EqualValues(t, 1, negotiated.GetThreshold())
+
+	before := proto.GetCompressionStats(proto.Compression_SNAPPY).NumCompress
+	resp, err = c.Call(ctx, &proto.InfoRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test", resp.GetResponse().GetInfo().GetServerName())
+	assert.Greater(t, proto.GetCompressionStats(proto.Compression_SNAPPY).NumCompress, before)
+
+	// Requests above the threshold are compressed by client, and decoded by server.
+	before = proto.GetCompressionStats(proto.Compression_SNAPPY).NumDecompress
+	resp, err = c.Call(ctx, &proto.LoginRequest{Username: strings.Repeat("a", 500)})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test", resp.GetResponse().GetInfo().GetServerName())
+	assert.Greater(t, proto.GetCompressionStats(proto.Compression_SNAPPY).NumDecompress, before)
+}
+
+func TestCompressionNotNegotiated(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.Compression = CompressionOption{Algorithms: []proto.Compression{proto.Compression_SNAPPY}}
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+	// Compressed frame is refused without negotiation, even if supported.
+	msg, _ := proto.NewRequestMessage(&proto.LoginRequest{Username: strings.Repeat("a", 256)})
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, proto.NewCodec().WithCompression(proto.Compression_SNAPPY, 0).Encode(msg, buf))
+	require.EqualValues(t, proto.Compression_SNAPPY, buf.Bytes()[0])
+
+	_, err = conn.Write(buf.Bytes())
+	require.NoError(t, err)
+
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,320 @@
+package server
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/sirupsen/logrus"
+	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/util"
+	pbproto "google.golang.org/protobuf/proto"
+)
+
+const (
+	datagramTokenSize    = 16
+	datagramReadBuffSize = 64 * 1024 // Max UDP payload
+
+	metricKeyDatagramReceived   = "server.datagram.received"
+	metricKeyDatagramSent       = "server.datagram.sent"
+	tplDatagramDroppedMetricKey = "server.datagram.dropped.%s"
+)
+
+// Reasons why the inbound datagrams are dropped.
+const (
+	DatagramDropMalformed       = "malformed"       // Not a valid datagram
+	DatagramDropUnbound         = "unbound"         // Token not bound to any session
+	DatagramDropUnauthenticated = "unauthenticated" // MAC not verified with the key bound
+	DatagramDropStale           = "stale"           // Sequence number not newer than the last received
+)
+
+var (
+	// DatagramDropReasons lists all the reasons why inbound datagrams are dropped.
+	DatagramDropReasons = []string{
+		DatagramDropMalformed, DatagramDropUnbound, DatagramDropUnauthenticated, DatagramDropStale,
+	}
+
+	errDatagramNotEnabled  = errors.New("datagram channel not enabled")
+	errDatagramNotBound    = errors.New("datagram channel not bound")
+	errDatagramAddrUnknown = errors.New("datagram client address not learned yet")
+	errDatagramNotAllowed  = errors.New("message not allowed over datagram channel")
+)
+
+// datagramChannel is the unreliable datagram channel bound to the session.
+type datagramChannel struct {
+	hub     *DatagramHub
+	session *Session
+	token   string
+	key     []byte                      // Secret key to authenticate the datagrams
+	addr    atomic.Pointer[net.UDPAddr] // Client address learned from the latest datagram
+	recvSeq atomic.Uint64               // Sequence number of the last received datagram
+	sendSeq uint64                      // Sequence number of the last sent datagram
+	sendMu  sync.Mutex                  // Keeps the sequence numbers in the sending order
+}
+
+// advance accepts the sequence number only if newer than the last received one,
+// which is only called by the read loop.
+func (c *datagramChannel) advance(seq uint64) bool {
+	if seq <= c.recvSeq.Load() {
+		return false
+	}
+
+	c.recvSeq.Store(seq)
+	return true
+}
+
+func (c *datagramChannel) send(msg *proto.Message) error {
+	addr := c.addr.Load()
+	if addr == nil {
+		return errDatagramAddrUnknown
+	}
+
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	data, err := pbproto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.sendSeq++
+	dg := &proto.Datagram{Seq: c.sendSeq, Message: data}
+	dg.Sign(c.key, true)
+
+	b, err := pbproto.Marshal(dg)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.hub.conn.WriteToUDP(b, addr); err != nil {
+		return err
+	}
+
+	metrics.GetOrRegisterCounter(metricKeyDatagramSent, nil).Inc(1)
+	return nil
+}
+
+// DatagramHub serves the unreliable datagram channels alongside KCP for high
+// frequency state, eg., positions, which are better lost than delayed by
+// retransmission.
+//
+// Each datagram is carried by a single UDP packet of protobuf encoded `Datagram`,
+// regardless of the codec of the listeners. The channel is bound to the session
+// authenticated over the reliable connection by DATAGRAM_BIND, whose token is
+// carried by every datagram sent by client. Since datagrams can be observed or
+// forged, each one is authenticated by HMAC with the secret key bound along with
+// the token, and the client address is learned from the latest authenticated
+// datagram. Stale datagrams are dropped by sequence number on both sides.
+type DatagramHub struct {
+	conn      *net.UDPConn
+	inherited bool   // Whether the socket inherited on hot restart
+	endpoint  string // Endpoint advertised to clients
+
+	mu       sync.RWMutex
+	channels map[string]*datagramChannel // Bound channels by token
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// NewDatagramHub listens on the UDP endpoint for datagrams, and advertises the
+// endpoint to clients on binding, which defaults to the listening one.
+func NewDatagramHub(addr, endpoint string) (*DatagramHub, error) {
+	conn, inherited, err := util.ListenUDP(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(endpoint) == 0 {
+		endpoint = addr
+	}
+
+	return &DatagramHub{
+		conn:      conn,
+		inherited: inherited,
+		endpoint:  endpoint,
+		channels:  make(map[string]*datagramChannel),
+		closed:    make(chan struct{}),
+	}, nil
+}
+
+// Addr returns the listening address.
+func (h *DatagramHub) Addr() net.Addr {
+	return h.conn.LocalAddr()
+}
+
+// Serve reads the datagrams, and serves the carried messages through the handler
+// chain with the bound sessions. It always returns a non-nil error, and after
+// closed the returned error is errServerClosed.
+func (h *DatagramHub) Serve(ch *ConnectionHandler) error {
+	if h.inherited {
+		// Inherited socket is still read by the previous process on hot restart.
+		select {
+		case <-util.Released():
+		case <-h.closed:
+			return errServerClosed
+		}
+	}
+
+	logrus.WithField("endpoint", h.Addr()).Info("Datagram channel started serving")
+
+	buf := make([]byte, datagramReadBuffSize)
+	for {
+		n, addr, err := h.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-h.closed:
+				return errServerClosed
+			default:
+			}
+
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+
+			logrus.WithError(err).Debug("Datagram channel failed to read")
+			continue
+		}
+
+		h.handle(ch, buf[:n], addr)
+	}
+}
+
+// handle serves the datagram inline, so handlers of high frequency state are
+// expected to be fast, and datagrams are lost once the socket buffer overflows.
+func (h *DatagramHub) handle(ch *ConnectionHandler, b []byte, addr *net.UDPAddr) {
+	var dg proto.Datagram
+	if err := pbproto.Unmarshal(b, &dg); err != nil {
+		dropDatagram(DatagramDropMalformed)
+		return
+	}
+
+	c := h.lookup(dg.Token)
+	if c == nil {
+		dropDatagram(DatagramDropUnbound)
+		return
+	}
+
+	// Authenticated before any state changed, otherwise the token observed could
+	// redirect the responses or lock out the client by the max sequence number.
+	if !dg.Verify(c.key, false) {
+		dropDatagram(DatagramDropUnauthenticated)
+		return
+	}
+
+	if !c.advance(dg.Seq) {
+		dropDatagram(DatagramDropStale)
+		return
+	}
+
+	metrics.GetOrRegisterCounter(metricKeyDatagramReceived, nil).Inc(1)
+
+	// Follow the client address, which may change due to NAT rebinding.
+	c.addr.Store(addr)
+	c.session.Refresh()
+
+	if len(dg.Message) == 0 {
+		// Probe only to learn the client address.
+		return
+	}
+
+	msg := new(proto.Message)
+	if err := pbproto.Unmarshal(dg.Message, msg); err != nil {
+		dropDatagram(DatagramDropMalformed)
+		return
+	}
+
+	var resp *proto.Message
+	if isHello(msg) || isNegotiation(msg) || msg.GetRequest().GetDatagramBind() != nil {
+		// Connection state is only changed over the reliable connection.
+		resp = NewMessageWithError(NewBadRequestError(errDatagramNotAllowed)).ProtoMessage()
+		resp.Seq = msg.Seq
+	} else {
+		resp = ch.serve(c.session, msg)
+	}
+
+	if err := c.send(resp); err != nil {
+		logrus.WithField("remoteAddr", addr).
+			WithError(err).
+			Debug("Datagram channel failed to send response message")
+	}
+}
+
+func (h *DatagramHub) lookup(token []byte) *datagramChannel {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.channels[string(token)]
+}
+
+// bind binds a new datagram channel with random token and key to the session.
+func (h *DatagramHub) bind(s *Session) (*datagramChannel, error) {
+	token := make([]byte, datagramTokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return nil, errors.WithMessage(err, "failed to generate token")
+	}
+
+	key := make([]byte, proto.DatagramKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.WithMessage(err, "failed to generate key")
+	}
+
+	c := &datagramChannel{hub: h, session: s, token: string(token), key: key}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.channels[c.token] = c
+	return c, nil
+}
+
+func (h *DatagramHub) unbind(c *datagramChannel) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.channels, c.token)
+}
+
+// Count returns the number of bound datagram channels.
+func (h *DatagramHub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.channels)
+}
+
+func (h *DatagramHub) Close() error {
+	err := errServerClosed
+	h.closeOnce.Do(func() {
+		close(h.closed)
+		err = h.conn.Close()
+	})
+
+	return err
+}
+
+// GetDatagramCounts returns the number of datagrams received, sent and dropped
+// for each reason.
+func GetDatagramCounts() map[string]int64 {
+	counts := map[string]int64{
+		"received": metrics.GetOrRegisterCounter(metricKeyDatagramReceived, nil).Count(),
+		"sent":     metrics.GetOrRegisterCounter(metricKeyDatagramSent, nil).Count(),
+	}
+	for _, r := range DatagramDropReasons {
+		counts["dropped "+r] = metrics.GetOrRegisterCounter(datagramDroppedMetricKey(r), nil).Count()
+	}
+
+	return counts
+}
+
+func dropDatagram(reason string) {
+	metrics.GetOrRegisterCounter(datagramDroppedMetricKey(reason), nil).Inc(1)
+}
+
+func datagramDroppedMetricKey(reason string) string {
+	return fmt.Sprintf(tplDatagramDroppedMetricKey, reason)
+}
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/client"
+	"github.com/wanliqun/cgo-game-server/proto"
+	pbproto "google.golang.org/protobuf/proto"
+)
+
+// lossyRelay relays datagrams between client and server over loopback, and drops
+// every nth datagram in each direction to simulate loss.
+type lossyRelay struct {
+	front   *net.UDPConn // Facing client
+	back    *net.UDPConn // Facing server
+	client  atomic.Pointer[net.UDPAddr]
+	dropped atomic.Int64
+}
+
+func newLossyRelay(t *testing.T, server net.Addr, nth int) *lossyRelay {
+	front, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	back, err := net.DialUDP("udp", nil, server.(*net.UDPAddr))
+	require.NoError(t, err)
+
+	r := &lossyRelay{front: front, back: back}
+	t.Cleanup(func() {
+		front.Close()
+		back.Close()
+	})
+
+	relay := func(read func([]byte) (int, error), write func([]byte) error) {
+		buf := make([]byte, datagramReadBuffSize)
+		for i := 1; ; i++ {
+			n, err := read(buf)
+			if err != nil {
+				return
+			}
+
+			if i%nth == 0 {
+				r.dropped.Add(1)
+				continue
+			}
+
+			write(buf[:n])
+		}
+	}
+
+	go relay(func(b []byte) (int, error) {
+		n, addr, err := front.ReadFromUDP(b)
+		if err == nil {
+			r.client.Store(addr)
+		}
+		return n, err
+	}, func(b []byte) error {
+		_, err := back.Write(b)
+		return err
+	})
+
+	go relay(back.Read, func(b []byte) error {
+		_, err := front.WriteToUDP(b, r.client.Load())
+		return err
+	})
+
+	return r
+}
+
+func newDatagramConnectionHandler(t *testing.T) *ConnectionHandler {
+	hub, err := NewDatagramHub("127.0.0.1:0", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { hub.Close() })
+
+	ch := newInfoConnectionHandler()
+	info := ch.Handler
+	ch.Handler = func(ctx context.Context, msg *Message) *Message {
+		if msg.GetRequest().GetDatagramBind() == nil {
+			return info(ctx, msg)
+		}
+
+		session, _ := SessionFromContext(ctx)
+		token, key, endpoint, err := session.BindDatagram()
+		if err != nil {
+			return NewMessageWithError(err)
+		}
+
+		resp, _ := proto.NewResponseMessage(&proto.DatagramBindResponse{
+			Token: token, Endpoint: endpoint, Key: key,
+		})
+		return NewMessage(resp)
+	}
+	ch.Datagram = hub
+
+	go hub.Serve(ch)
+	return ch
+}
+
+func TestDatagramChannel(t *testing.T) {
+	ch := newDatagramConnectionHandler(t)
+	relay := newLossyRelay(t, ch.Datagram.Addr(), 4)
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+	go srv.Serve()
+	defer srv.Close()
+
+	c := client.NewTCPClient(
+		srv.listener.Addr().String(), client.WithDatagramEndpoint(relay.front.LocalAddr().String()),
+	)
+	require.NoError(t, c.Connect())
+	defer c.Close()
+
+	var received atomic.Int64
+	c.OnMessage(func(msg *proto.Message) {
+		if msg.GetResponse().GetInfo() != nil {
+			received.Add(1)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	require.NoError(t, c.BindDatagram(ctx))
+	assert.Equal(t, 1, ch.Datagram.Count())
+
+	before := GetDatagramCounts()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.SendDatagram(&proto.InfoRequest{}))
+		time.Sleep(time.Millisecond)
+	}
+
+	// 25 of the probe and 100 requests are lost, and 18 of the 75 responses.
+	assert.Eventually(t, func() bool {
+		return received.Load() == 57
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, 43, relay.dropped.Load())
+
+	after := GetDatagramCounts()
+	assert.EqualValues(t, 76, after["received"]-before["received"])
+	assert.EqualValues(t, 75, after["sent"]-before["sent"])
+	assert.Zero(t, after["dropped stale"]-before["dropped stale"])
+
+	// Unbound once the session closed.
+	c.Close()
+	assert.Eventually(t, func() bool {
+		return ch.Datagram.Count() == 0
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+// datagram is the authenticated datagram received from server.
+type datagram struct {
+	Seq     uint64
+	Message *proto.Message
+}
+
+func dialDatagram(t *testing.T, hub *DatagramHub) *net.UDPConn {
+	udp, err := net.DialUDP("udp", nil, hub.Addr().(*net.UDPAddr))
+	require.NoError(t, err)
+	t.Cleanup(func() { udp.Close() })
+
+	return udp
+}
+
+// sendDatagram sends the PING request with the sequence number as timestamp.
+func sendDatagram(t *testing.T, udp *net.UDPConn, token, key []byte, seq uint64) {
+	msg, _ := proto.NewRequestMessage(&proto.PingRequest{Timestamp: int64(seq)})
+	data, _ := pbproto.Marshal(msg)
+
+	dg := &proto.Datagram{Token: token, Seq: seq, Message: data}
+	dg.Sign(key, false)
+
+	b, _ := pbproto.Marshal(dg)
+	_, err := udp.Write(b)
+	require.NoError(t, err)
+}
+
+func recvDatagram(t *testing.T, udp *net.UDPConn, key []byte) *datagram {
+	udp.SetReadDeadline(time.Now().Add(3 * time.Second))
+
+	buf := make([]byte, datagramReadBuffSize)
+	n, err := udp.Read(buf)
+	require.NoError(t, err)
+
+	var dg proto.Datagram
+	require.NoError(t, pbproto.Unmarshal(buf[:n], &dg))
+	require.True(t, dg.Verify(key, true))
+
+	msg := new(proto.Message)
+	require.NoError(t, pbproto.Unmarshal(dg.Message, msg))
+	return &datagram{Seq: dg.Seq, Message: msg}
+}
+
+func TestDatagramStale(t *testing.T) {
+	ch := newDatagramConnectionHandler(t)
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	session := newBufferedSession(conn, ch.Codec, ch.Outbound)
+	session.datagrams = ch.Datagram
+
+	assert.ErrorIs(t, session.SendDatagram(&proto.Message{}), errDatagramNotBound)
+
+	token, key, _, err := session.BindDatagram()
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, session.SendDatagram(&proto.Message{}), errDatagramAddrUnknown)
+
+	udp := dialDatagram(t, ch.Datagram)
+	send := func(token []byte, seq uint64) {
+		sendDatagram(t, udp, token, key, seq)
+	}
+	recv := func() *datagram {
+		return recvDatagram(t, udp, key)
+	}
+
+	before := GetDatagramCounts()
+
+	// Datagrams are served in order, so all handled once the last responded.
+	udp.Write([]byte{0xff})
+	send([]byte("unbound"), 1)
+	for _, seq := range []uint64{1, 3, 2, 3, 4} {
+		send(token, seq)
+	}
+
+	for i, ts := range []int64{1, 3, 4} {
+		dg := recv()
+		assert.EqualValues(t, i+1, dg.Seq)
+		assert.Equal(t, ts, dg.Message.GetResponse().GetPong().GetTimestamp())
+	}
+
+	after := GetDatagramCounts()
+	assert.EqualValues(t, 1, after["dropped malformed"]-before["dropped malformed"])
+	assert.EqualValues(t, 1, after["dropped unbound"]-before["dropped unbound"])
+	assert.EqualValues(t, 2, after["dropped stale"]-before["dropped stale"])
+
+	// Pushed to the client address learned.
+	require.NoError(t, session.SendDatagram(&proto.Message{Seq: 100}))
+	assert.EqualValues(t, 100, recv().Message.Seq)
+
+	session.Close()
+	assert.Zero(t, ch.Datagram.Count())
+	assert.ErrorIs(t, session.SendDatagram(&proto.Message{}), errDatagramNotBound)
+}
+
+func TestDatagramForged(t *testing.T) {
+	ch := newDatagramConnectionHandler(t)
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	session := newBufferedSession(conn, ch.Codec, ch.Outbound)
+	session.datagrams = ch.Datagram
+	defer session.Close()
+
+	token, key, _, err := session.BindDatagram()
+	require.NoError(t, err)
+
+	udp := dialDatagram(t, ch.Datagram)
+	sendDatagram(t, udp, token, key, 1)
+	assert.EqualValues(t, 1, recvDatagram(t, udp, key).Seq)
+
+	before := GetDatagramCounts()
+
+	// Forged by the token observed from another address, eg., to redirect the
+	// responses or to lock out the client by the max sequence number.
+	attacker := dialDatagram(t, ch.Datagram)
+	sendDatagram(t, attacker, token, nil, math.MaxUint64)
+	sendDatagram(t, attacker, token, []byte("guessed key"), math.MaxUint64)
+
+	// Datagram reflected back to server is not authenticated either.
+	msg, _ := proto.NewRequestMessage(&proto.PingRequest{})
+	data, _ := pbproto.Marshal(msg)
+	reflected := &proto.Datagram{Token: token, Seq: math.MaxUint64, Message: data}
+	reflected.Sign(key, true)
+	b, _ := pbproto.Marshal(reflected)
+	_, err = attacker.Write(b)
+	require.NoError(t, err)
+
+	// Client is still served at its own address.
+	sendDatagram(t, udp, token, key, 2)
+	dg := recvDatagram(t, udp, key)
+	assert.EqualValues(t, 2, dg.Seq)
+	assert.EqualValues(t, 2, dg.Message.GetResponse().GetPong().GetTimestamp())
+
+	after := GetDatagramCounts()
+	assert.EqualValues(t, 3, after["dropped unauthenticated"]-before["dropped unauthenticated"])
+	assert.Zero(t, after["dropped stale"]-before["dropped stale"])
+
+	attacker.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, err = attacker.Read(make([]byte, datagramReadBuffSize))
+	assert.Error(t, err)
+}
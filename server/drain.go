@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+const (
+	drainPollInterval = 50 * time.Millisecond
+)
+
+var (
+	ErrServerDraining = &StatusError{
+		Code: StatusServerDraining,
+		Err:  errors.New("server draining"),
+	}
+)
+
+// DrainStatus is a snapshot of the drain progress.
+type DrainStatus struct {
+	Draining         bool
+	Elapsed          time.Duration // Elapsed time since draining started
+	InFlightRequests int64         // Number of requests still being handled
+}
+
+// DrainOption configures the drain phase before shutdown.
+type DrainOption struct {
+	Timeout           time.Duration // Max duration to wait for in-flight requests
+	ReconnectAfter    time.Duration // Hint of the delay before reconnecting
+	ReconnectEndpoint string        // Hint of the endpoint to reconnect
+}
+
+// Drainer coordinates the drain phase before shutdown, during which new connections
+// and logins are rejected, and in-flight requests are given time to finish.
+type Drainer struct {
+	option    DrainOption
+	startedAt atomic.Int64 // Unix nanoseconds when draining started, 0 if not draining
+	inflight  atomic.Int64 // Number of in-flight requests
+}
+
+func NewDrainer(option DrainOption) *Drainer {
+	return &Drainer{option: option}
+}
+
+// Draining returns whether the server is draining.
+func (d *Drainer) Draining() bool {
+	return d.startedAt.Load() != 0
+}
+
+// Status returns the drain progress.
+func (d *Drainer) Status() *DrainStatus {
+	status := &DrainStatus{InFlightRequests: d.inflight.Load()}
+	if startedAt := d.startedAt.Load(); startedAt != 0 {
+		status.Draining = true
+		status.Elapsed = time.Since(time.Unix(0, startedAt))
+	}
+
+	return status
+}
+
+// track tracks the request being handled, and returns a function to call once done.
+func (d *Drainer) track() func() {
+	d.inflight.Add(1)
+	return func() { d.inflight.Add(-1) }
+}
+
+// Drain starts draining by pushing shutdown notice to all the sessions, then waits
+// until all in-flight requests are handled or the drain timeout elapsed.
+func (d *Drainer) Drain(mgr *SessionManager, reason string) error {
+	if !d.startedAt.CompareAndSwap(0, time.Now().UnixNano()) {
+		return nil
+	}
+
+	notice, _ := proto.NewEventMessage(&proto.ShutdownEvent{
+		Reason:            reason,
+		DrainTimeoutMs:    d.option.Timeout.Milliseconds(),
+		ReconnectAfterMs:  d.option.ReconnectAfter.Milliseconds(),
+		ReconnectEndpoint: d.option.ReconnectEndpoint,
+	})
+
+	sessions := mgr.ListAll()
+	for _, s := range sessions {
+		if err := s.Push(notice); err != nil {
+			logrus.WithField("sessionID", s.ID).
+				WithError(err).
+				Debug("Failed to push shutdown notice")
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"sessions": len(sessions),
+		"timeout":  d.option.Timeout,
+	}).Info("Server started draining")
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.option.Timeout)
+	defer cancel()
+
+	return d.waitIdle(ctx)
+}
+
+// waitIdle waits until there is no in-flight request.
+func (d *Drainer) waitIdle(ctx context.Context) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for d.inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
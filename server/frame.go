@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+const (
+	tplFrameViolationMetricKey = "server.frame.violation.%s"
+
+	defaultReadBufferSize = 4096
+)
+
+// Frame violations, each of which disconnects the session.
+const (
+	FrameViolationTooLarge    = "too_large"    // Frame length exceeds the limit
+	FrameViolationTruncated   = "truncated"    // Connection closed in the middle of a frame
+	FrameViolationReadTimeout = "read_timeout" // Frame not read in time since its first byte arrived
+	FrameViolationBodyTimeout = "body_timeout" // Frame data not read in time since its length decoded
+)
+
+var (
+	// FrameViolations lists all the frame violations.
+	FrameViolations = []string{
+		FrameViolationTooLarge,
+		FrameViolationTruncated,
+		FrameViolationReadTimeout,
+		FrameViolationBodyTimeout,
+	}
+)
+
+// FrameOption hardens the frame decoding against broken or malicious clients,
+// eg., giant frame length or slowloris attack by sending frames slowly.
+type FrameOption struct {
+	// Max frame length in bytes, 0 means only limited by the codec (16MB).
+	MaxSize int
+	// Max duration to read a whole frame since its first byte arrived, 0 means unlimited.
+	ReadTimeout time.Duration
+	// Max duration to read the frame data since the frame length decoded, 0 means unlimited.
+	HeaderToBodyTimeout time.Duration
+}
+
+// FrameViolationError indicates the frame violation, which disconnects the session.
+type FrameViolationError struct {
+	Reason string
+	Err    error
+}
+
+func (e *FrameViolationError) Error() string {
+	return fmt.Sprintf("frame violation (%s): %v", e.Reason, e.Err)
+}
+
+func (e *FrameViolationError) Unwrap() error {
+	return e.Err
+}
+
+// asFrameViolation classifies the decoding error as the frame violation, or
+// returns nil if not violated, eg., the connection closed between frames.
+func asFrameViolation(err error) *FrameViolationError {
+	var fe *FrameViolationError
+	switch {
+	case errors.As(err, &fe):
+		return fe
+	case errors.Is(err, proto.ErrFrameTooLarge):
+		return &FrameViolationError{Reason: FrameViolationTooLarge, Err: err}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return &FrameViolationError{Reason: FrameViolationTruncated, Err: err}
+	default:
+		return nil
+	}
+}
+
+// GetFrameViolationCounts returns the number of sessions disconnected by each
+// frame violation.
+func GetFrameViolationCounts() map[string]int64 {
+	counts := make(map[string]int64, len(FrameViolations))
+	for _, v := range FrameViolations {
+		counts[v] = metrics.GetOrRegisterCounter(frameViolationMetricKey(v), nil).Count()
+	}
+
+	return counts
+}
+
+func frameViolationMetricKey(reason string) string {
+	return fmt.Sprintf(tplFrameViolationMetricKey, reason)
+}
+
+// frameDecoder reads the inbound frames of the session for the codec to decode.
+type frameDecoder interface {
+	io.Reader
+	reset() // Resets before decoding the next frame
+}
+
+// bufferedReader reads from the buffer of the connection, eg., `bufio.Reader`.
+type bufferedReader interface {
+	io.Reader
+	io.ByteReader
+	ReadSlice(delim byte) ([]byte, error)
+}
+
+// frameReader enforces the frame option while reading frames from the buffered
+// connection. The read deadline is armed only once the first byte of the frame
+// arrives, so idle sessions are left to the heartbeat timeout instead.
+type frameReader struct {
+	r        bufferedReader
+	conn     net.Conn
+	opt      FrameOption
+	started  bool      // Whether the first byte of the frame arrived
+	deadline time.Time // Armed read deadline, zero if not armed
+	reason   string    // Violation once the armed read deadline exceeded
+}
+
+func newFrameDecoder(conn net.Conn, opt FrameOption) frameDecoder {
+	br, ok := conn.(bufferedReader)
+	if !ok {
+		br = bufio.NewReaderSize(conn, defaultReadBufferSize)
+	}
+
+	return &frameReader{r: br, conn: conn, opt: opt}
+}
+
+func (r *frameReader) reset() {
+	if !r.deadline.IsZero() {
+		r.conn.SetReadDeadline(time.Time{})
+	}
+
+	r.started, r.deadline, r.reason = false, time.Time{}, ""
+}
+
+func (r *frameReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.onRead(n)
+	return n, r.check(err)
+}
+
+func (r *frameReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.onRead(1)
+	}
+	return b, r.check(err)
+}
+
+// ReadSlice reads until the delimiter for line-delimited codecs.
+func (r *frameReader) ReadSlice(delim byte) ([]byte, error) {
+	line, err := r.r.ReadSlice(delim)
+	r.onRead(len(line))
+	return line, r.check(err)
+}
+
+// OnFrameHeader enforces the frame size limit, and arms the read deadline of
+// the frame data.
+func (r *frameReader) OnFrameHeader(size int) error {
+	if r.opt.MaxSize > 0 && size > r.opt.MaxSize {
+		return &FrameViolationError{
+			Reason: FrameViolationTooLarge,
+			Err:    errors.Errorf("frame length %d exceeds limit %d", size, r.opt.MaxSize),
+		}
+	}
+
+	if r.opt.HeaderToBodyTimeout > 0 {
+		r.arm(time.Now().Add(r.opt.HeaderToBodyTimeout), FrameViolationBodyTimeout)
+	}
+
+	return nil
+}
+
+func (r *frameReader) onRead(n int) {
+	if n > 0 && !r.started {
+		r.started = true
+
+		if r.opt.ReadTimeout > 0 {
+			r.arm(time.Now().Add(r.opt.ReadTimeout), FrameViolationReadTimeout)
+		}
+	}
+}
+
+// arm sets the read deadline unless an earlier one already armed.
+func (r *frameReader) arm(deadline time.Time, reason string) {
+	if !r.deadline.IsZero() && !deadline.Before(r.deadline) {
+		return
+	}
+
+	r.deadline, r.reason = deadline, reason
+	r.conn.SetReadDeadline(deadline)
+}
+
+// check classifies the timeout of the armed read deadline as frame violation.
+func (r *frameReader) check(err error) error {
+	var ne net.Error
+	if err != nil && r.reason != "" && errors.As(err, &ne) && ne.Timeout() {
+		return &FrameViolationError{Reason: r.reason, Err: err}
+	}
+
+	return err
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+func TestFrameViolations(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.Frame = FrameOption{
+		MaxSize:             64,
+		ReadTimeout:         300 * time.Millisecond,
+		HeaderToBodyTimeout: 100 * time.Millisecond,
+	}
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	header := func(size uint32) []byte {
+		return binary.BigEndian.AppendUint32(nil, size)
+	}
+
+	testCases := []struct {
+		reason string
+		send   func(conn *net.TCPConn)
+	}{
+		{FrameViolationTooLarge, func(conn *net.TCPConn) {
+			conn.Write(header(1 << 20))
+		}},
+		{FrameViolationBodyTimeout, func(conn *net.TCPConn) {
+			conn.Write(header(16))
+		}},
+		{FrameViolationReadTimeout, func(conn *net.TCPConn) {
+			conn.Write(header(16)[:2])
+		}},
+		{FrameViolationTruncated, func(conn *net.TCPConn) {
+			conn.Write(append(header(16), 1, 2, 3))
+			conn.CloseWrite()
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.reason, func(t *testing.T) {
+			before := GetFrameViolationCounts()[tc.reason]
+
+			conn, err := net.Dial("tcp", srv.listener.Addr().String())
+			require.NoError(t, err)
+			defer conn.Close()
+
+			conn.SetDeadline(time.Now().Add(3 * time.Second))
+			tc.send(conn.(*net.TCPConn))
+
+			// Session is disconnected once violated.
+			_, err = io.ReadAll(conn)
+			require.NoError(t, err)
+
+			assert.Equal(t, before+1, GetFrameViolationCounts()[tc.reason])
+		})
+	}
+}
+
+func TestFramePartialRead(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.Frame = FrameOption{ReadTimeout: time.Second, HeaderToBodyTimeout: time.Second}
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+	codec := proto.NewCodec()
+	msg, err := proto.NewRequestMessage(&proto.InfoRequest{})
+	require.NoError(t, err)
+
+	pw := &partialWriter{conn: conn}
+	require.NoError(t, codec.Encode(msg, pw))
+
+	resp, err := codec.Decode(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "test", resp.GetResponse().GetInfo().GetServerName())
+}
+
+// partialWriter writes the frame byte by byte with pauses.
+type partialWriter struct {
+	conn net.Conn
+}
+
+func (w *partialWriter) Write(p []byte) (int, error) {
+	for i := range p {
+		if _, err := w.conn.Write(p[i : i+1]); err != nil {
+			return i, err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return len(p), nil
+}
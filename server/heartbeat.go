@@ -0,0 +1,52 @@
+package server
+
+import (
+	"time"
+
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+const (
+	defaultHeartbeatInterval = 10 * time.Second
+	defaultHeartbeatTimeout  = 30 * time.Second
+)
+
+// HeartbeatOption configures the liveness policy agreed by client and server, ie.,
+// client sends a PING every interval, and the session is considered dead if nothing
+// received within the timeout.
+type HeartbeatOption struct {
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+func (o HeartbeatOption) interval() time.Duration {
+	if o.Interval > 0 {
+		return o.Interval
+	}
+	return defaultHeartbeatInterval
+}
+
+func (o HeartbeatOption) timeout() time.Duration {
+	if o.Timeout > 0 {
+		return o.Timeout
+	}
+	return defaultHeartbeatTimeout
+}
+
+// isHeartbeat checks if the message is a PING heartbeat, which is handled
+// by the connection handler directly without going through the handler chain.
+func isHeartbeat(msg *proto.Message) bool {
+	return msg.Type == proto.MessageType_PING && msg.GetRequest().GetPing() != nil
+}
+
+// pong responds the PING heartbeat with the agreed liveness policy.
+func (ch *ConnectionHandler) pong(msg *proto.Message) *proto.Message {
+	resp, _ := proto.NewResponseMessage(&proto.PongResponse{
+		Timestamp:  msg.GetRequest().GetPing().GetTimestamp(),
+		IntervalMs: ch.Heartbeat.interval().Milliseconds(),
+		TimeoutMs:  ch.Heartbeat.timeout().Milliseconds(),
+	})
+	resp.Seq = msg.Seq
+
+	return resp
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/client"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+func TestHeartbeat(t *testing.T) {
+	var handled atomic.Int32
+
+	ch := newInfoConnectionHandler()
+	ch.Handler = func(ctx context.Context, msg *Message) *Message {
+		handled.Add(1)
+		return NewMessageWithError(nil)
+	}
+	ch.Heartbeat = HeartbeatOption{Interval: 2 * time.Second, Timeout: 5 * time.Second}
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+	ping, _ := proto.NewRequestMessage(&proto.PingRequest{Timestamp: 12345})
+	ping.Seq = 7
+
+	codec := proto.NewCodec()
+	require.NoError(t, codec.Encode(ping, conn))
+
+	// PONG echoes the timestamp along with the agreed liveness policy.
+	resp, err := codec.Decode(conn)
+	require.NoError(t, err)
+
+	pong := resp.GetResponse().GetPong()
+	require.NotNil(t, pong)
+	assert.EqualValues(t, 7, resp.Seq)
+	assert.EqualValues(t, 12345, pong.Timestamp)
+	assert.EqualValues(t, 2000, pong.IntervalMs)
+	assert.EqualValues(t, 5000, pong.TimeoutMs)
+
+	// Heartbeat bypasses the handler chain.
+	assert.Zero(t, handled.Load())
+}
+
+func TestHeartbeatLiveness(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.SessManager = NewSessionManager(time.Second)
+	ch.Heartbeat = HeartbeatOption{Interval: 100 * time.Millisecond, Timeout: time.Second}
+
+	go ch.SessManager.Start()
+	defer ch.SessManager.Stop()
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	addr := srv.listener.Addr().String()
+
+	// Client keeps alive by heartbeats, while the idle connection expires.
+	c := client.NewTCPClient(addr, client.WithHeartbeat(100*time.Millisecond, time.Second))
+	require.NoError(t, c.Connect())
+	defer c.Close()
+
+	idle, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer idle.Close()
+
+	require.Eventually(t, func() bool {
+		return ch.SessManager.Count() == 2
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return ch.SessManager.Count() == 1
+	}, 5*time.Second, 50*time.Millisecond)
+
+	idle.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = idle.Read(make([]byte, 1))
+	assert.Error(t, err)
+
+	// Still alive beyond the timeout.
+	time.Sleep(1500 * time.Millisecond)
+	assert.Equal(t, 1, ch.SessManager.Count())
+}
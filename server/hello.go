@@ -0,0 +1,143 @@
+package server
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+var (
+	errHandshakeRequired = &StatusError{
+		Code: StatusHandshakeRequired,
+		Err:  errors.New("HELLO handshake required as the first message"),
+	}
+)
+
+// HandshakeOption configures the HELLO handshake exchanged on every connection.
+type HandshakeOption struct {
+	// Whether HELLO must be the first message on every connection.
+	Required bool
+	// Min protocol version supported, defaults to the current protocol version.
+	MinProtocolVersion uint32
+	// Feature capabilities supported by server.
+	Capabilities []string
+}
+
+func (o HandshakeOption) minProtocolVersion() uint32 {
+	if o.MinProtocolVersion > 0 {
+		return o.MinProtocolVersion
+	}
+	return proto.ProtocolVersion
+}
+
+func (o HandshakeOption) supports(capability string) bool {
+	for _, v := range o.Capabilities {
+		if v == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Handshake is the client information exchanged by the HELLO handshake.
+type Handshake struct {
+	ProtocolVersion uint32          // Protocol version spoken by client
+	ClientVersion   string          // Client build version
+	Platform        string          // Client platform
+	Capabilities    map[string]bool // Capabilities supported by both client and server
+}
+
+// isHello checks if the message is a HELLO request, which is handled by the
+// connection handler directly without going through the handler chain.
+func isHello(msg *proto.Message) bool {
+	return msg.Type == proto.MessageType_HELLO && msg.GetRequest().GetHello() != nil
+}
+
+// handshake requires HELLO as the first message of the session, and returns
+// false if the session should be closed.
+func (ch *ConnectionHandler) handshake(logger *logrus.Entry, session *Session) bool {
+	msg, err := ch.readMessage(session)
+	if err != nil {
+		logger.WithError(err).
+			Debug("Codec failed to decode proto message")
+		return false
+	}
+
+	return ch.handleHandshake(logger, session, msg)
+}
+
+// handleHandshake responds the first message of the session, which must be HELLO,
+// and returns false if the session should be closed.
+func (ch *ConnectionHandler) handleHandshake(
+	logger *logrus.Entry, session *Session, msg *proto.Message) bool {
+	var (
+		resp *proto.Message
+		err  error
+	)
+	if isHello(msg) {
+		resp, err = ch.hello(session, msg)
+	} else {
+		err = errHandshakeRequired
+		resp = NewMessageWithError(err).ProtoMessage()
+		resp.Seq = msg.Seq
+	}
+
+	if err != nil {
+		logger.WithError(err).Debug("Connection handshake rejected")
+	}
+
+	if serr := session.Send(resp); serr != nil {
+		logger.WithError(serr).
+			Debug("Session failed to send response message")
+		return false
+	}
+
+	session.Refresh()
+	return err == nil
+}
+
+// hello checks the protocol version, and records the capabilities supported by
+// both client and server on the session.
+func (ch *ConnectionHandler) hello(session *Session, msg *proto.Message) (*proto.Message, error) {
+	req := msg.GetRequest().GetHello()
+
+	var resp *proto.Message
+	minVersion := ch.Handshake.minProtocolVersion()
+	if req.ProtocolVersion < minVersion || req.ProtocolVersion > proto.ProtocolVersion {
+		err := &StatusError{
+			Code: StatusUnsupportedProtocolVersion,
+			Err: errors.Errorf(
+				"unsupported protocol version %d, expected [%d, %d]",
+				req.ProtocolVersion, minVersion, proto.ProtocolVersion,
+			),
+		}
+
+		resp = NewMessageWithError(err).ProtoMessage()
+		resp.Seq = msg.Seq
+		return resp, err
+	}
+
+	hs := &Handshake{
+		ProtocolVersion: req.ProtocolVersion,
+		ClientVersion:   req.ClientVersion,
+		Platform:        req.Platform,
+		Capabilities:    make(map[string]bool),
+	}
+
+	var agreed []string
+	for _, c := range req.Capabilities {
+		if ch.Handshake.supports(c) && !hs.Capabilities[c] {
+			hs.Capabilities[c] = true
+			agreed = append(agreed, c)
+		}
+	}
+	session.SetHandshake(hs)
+
+	resp, _ = proto.NewResponseMessage(&proto.HelloResponse{
+		ProtocolVersion: proto.ProtocolVersion,
+		Capabilities:    agreed,
+	})
+	resp.Seq = msg.Seq
+
+	return resp, nil
+}
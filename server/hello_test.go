@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/client"
+	"github.com/wanliqun/cgo-game-server/proto"
+	pbproto "google.golang.org/protobuf/proto"
+)
+
+func TestHandshake(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.Handshake = HandshakeOption{
+		Required:     true,
+		Capabilities: []string{proto.CapabilityHeartbeat},
+	}
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	addr := srv.listener.Addr().String()
+
+	t.Run("Accepted", func(t *testing.T) {
+		c := client.NewTCPClient(addr, client.WithClientInfo("1.2.3", "test"))
+		require.NoError(t, c.Connect())
+		defer c.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		resp, err := c.Call(ctx, &proto.InfoRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "test", resp.GetResponse().GetInfo().GetServerName())
+
+		assert.True(t, c.HasCapability(proto.CapabilityHeartbeat))
+		assert.False(t, c.HasCapability(proto.CapabilityCompression))
+
+		sessions := ch.SessManager.ListAll()
+		require.Len(t, sessions, 1)
+
+		hs := sessions[0].Handshake()
+		require.NotNil(t, hs)
+		assert.Equal(t, "1.2.3", hs.ClientVersion)
+		assert.Equal(t, "test", hs.Platform)
+		assert.True(t, sessions[0].HasCapability(proto.CapabilityHeartbeat))
+		assert.False(t, sessions[0].HasCapability(proto.CapabilityShutdown))
+	})
+
+	rejected := []struct {
+		name   string
+		req    pbproto.Message
+		status StatusCode
+	}{
+		{"NoHello", &proto.InfoRequest{}, StatusHandshakeRequired},
+		{
+			"UnsupportedVersion",
+			&proto.HelloRequest{ProtocolVersion: proto.ProtocolVersion + 1},
+			StatusUnsupportedProtocolVersion,
+		},
+	}
+
+	for _, tc := range rejected {
+		t.Run(tc.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", addr)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+			codec := proto.NewCodec()
+			msg, err := proto.NewRequestMessage(tc.req)
+			require.NoError(t, err)
+			require.NoError(t, codec.Encode(msg, conn))
+
+			resp, err := codec.Decode(conn)
+			require.NoError(t, err)
+			assert.Equal(t, tc.status, resp.GetResponse().GetStatus().GetCode())
+
+			// Connection is closed once rejected.
+			_, err = codec.Decode(conn)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/wanliqun/cgo-game-server/util"
+)
+
+const (
+	tplIPLimitRejectedMetricKey = "server.iplimit.rejected.%s"
+
+	ipLimitSweepInterval = time.Minute
+)
+
+// IP limit violations, each of which closes the connection immediately.
+const (
+	IPLimitMaxConns   = "max_conns"   // Concurrent connections of the IP exceed the cap
+	IPLimitAcceptRate = "accept_rate" // Accept rate of the IP exceeds the token bucket
+)
+
+var (
+	// IPLimitViolations lists all the IP limit violations.
+	IPLimitViolations = []string{IPLimitMaxConns, IPLimitAcceptRate}
+)
+
+// IPLimitOption configures the per-IP limits of new connections.
+type IPLimitOption struct {
+	MaxConns    int          // Max concurrent connections per IP, 0 means unlimited
+	AcceptRate  float64      // Token bucket refill rate of accepted connections per second per IP, 0 means unlimited
+	AcceptBurst int          // Token bucket size, defaults to 1 if rate limited
+	Exempt      util.CIDRSet // Exempted sources, eg., test rigs
+}
+
+// ipEntry tracks the connections of a source IP.
+type ipEntry struct {
+	conns  int       // Concurrent connections
+	tokens float64   // Available tokens of the accept rate bucket
+	last   time.Time // Last time the tokens refilled
+}
+
+// IPLimiter limits the concurrent connections and accept rate per source IP
+// across all transports.
+type IPLimiter struct {
+	option    IPLimitOption
+	mu        sync.Mutex
+	entries   map[string]*ipEntry // IP => entry
+	lastSweep time.Time
+}
+
+func NewIPLimiter(opt IPLimitOption) *IPLimiter {
+	if opt.AcceptRate > 0 && opt.AcceptBurst <= 0 {
+		opt.AcceptBurst = 1
+	}
+
+	return &IPLimiter{
+		option:    opt,
+		entries:   make(map[string]*ipEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Acquire tries to admit a new connection of the IP, and returns the violation
+// if refused. An admitted connection must be released later.
+func (l *IPLimiter) Acquire(ip net.IP) (violation string, ok bool) {
+	if l.option.Exempt.Contains(ip) {
+		return "", true
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	key := ip.String()
+	e := l.entries[key]
+	if e == nil {
+		e = &ipEntry{tokens: float64(l.option.AcceptBurst), last: now}
+		l.entries[key] = e
+	}
+
+	if l.option.MaxConns > 0 && e.conns >= l.option.MaxConns {
+		return l.reject(IPLimitMaxConns)
+	}
+
+	if l.option.AcceptRate > 0 {
+		l.refill(e, now)
+		if e.tokens < 1 {
+			return l.reject(IPLimitAcceptRate)
+		}
+		e.tokens--
+	}
+
+	e.conns++
+	return "", true
+}
+
+// Release releases an admitted connection of the IP.
+func (l *IPLimiter) Release(ip net.IP) {
+	if l.option.Exempt.Contains(ip) {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e := l.entries[ip.String()]; e != nil && e.conns > 0 {
+		e.conns--
+	}
+}
+
+func (l *IPLimiter) reject(violation string) (string, bool) {
+	metrics.GetOrRegisterCounter(ipLimitRejectedMetricKey(violation), nil).Inc(1)
+	return violation, false
+}
+
+func (l *IPLimiter) refill(e *ipEntry, now time.Time) {
+	e.tokens += now.Sub(e.last).Seconds() * l.option.AcceptRate
+	if burst := float64(l.option.AcceptBurst); e.tokens > burst {
+		e.tokens = burst
+	}
+	e.last = now
+}
+
+// sweep removes the idle entries periodically, whose token buckets are full,
+// so that removing them doesn't loosen the accept rate.
+func (l *IPLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < ipLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, e := range l.entries {
+		if e.conns > 0 {
+			continue
+		}
+
+		if l.option.AcceptRate > 0 {
+			if l.refill(e, now); e.tokens < float64(l.option.AcceptBurst) {
+				continue
+			}
+		}
+
+		delete(l.entries, key)
+	}
+}
+
+// GetIPLimitRejections returns the number of connections rejected by each IP
+// limit violation.
+func GetIPLimitRejections() map[string]int64 {
+	counts := make(map[string]int64, len(IPLimitViolations))
+	for _, v := range IPLimitViolations {
+		counts[v] = metrics.GetOrRegisterCounter(ipLimitRejectedMetricKey(v), nil).Count()
+	}
+
+	return counts
+}
+
+func ipLimitRejectedMetricKey(violation string) string {
+	return fmt.Sprintf(tplIPLimitRejectedMetricKey, violation)
+}
+
+// addrIP returns the IP of the TCP or UDP address, or nil otherwise.
+func addrIP(addr net.Addr) net.IP {
+	switch v := addr.(type) {
+	case *net.TCPAddr:
+		return v.IP
+	case *net.UDPAddr:
+		return v.IP
+	default:
+		return nil
+	}
+}
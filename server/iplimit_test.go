@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/client"
+	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/util"
+)
+
+func TestIPLimiter(t *testing.T) {
+	ip, exempted := net.ParseIP("1.2.3.4"), net.ParseIP("10.0.0.1")
+	exempt, err := util.ParseCIDRs([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	t.Run("MaxConns", func(t *testing.T) {
+		l := NewIPLimiter(IPLimitOption{MaxConns: 2, Exempt: exempt})
+		for i := 0; i < 2; i++ {
+			_, ok := l.Acquire(ip)
+			assert.True(t, ok)
+		}
+
+		violation, ok := l.Acquire(ip)
+		assert.False(t, ok)
+		assert.Equal(t, IPLimitMaxConns, violation)
+
+		l.Release(ip)
+		_, ok = l.Acquire(ip)
+		assert.True(t, ok)
+
+		for i := 0; i < 3; i++ {
+			_, ok = l.Acquire(exempted)
+			assert.True(t, ok)
+		}
+	})
+
+	t.Run("AcceptRate", func(t *testing.T) {
+		l := NewIPLimiter(IPLimitOption{AcceptRate: 10, AcceptBurst: 2})
+		for i := 0; i < 2; i++ {
+			_, ok := l.Acquire(ip)
+			assert.True(t, ok)
+			l.Release(ip)
+		}
+
+		violation, ok := l.Acquire(ip)
+		assert.False(t, ok)
+		assert.Equal(t, IPLimitAcceptRate, violation)
+
+		// Refilled after 1/rate second.
+		time.Sleep(150 * time.Millisecond)
+		_, ok = l.Acquire(ip)
+		assert.True(t, ok)
+	})
+}
+
+func TestIPLimiterServer(t *testing.T) {
+	ch := newInfoConnectionHandler()
+	ch.IPLimiter = NewIPLimiter(IPLimitOption{MaxConns: 1})
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	addr := srv.listener.Addr().String()
+
+	c := client.NewTCPClient(addr)
+	require.NoError(t, c.Connect())
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = c.Call(ctx, &proto.InfoRequest{})
+	require.NoError(t, err)
+
+	before := GetIPLimitRejections()[IPLimitMaxConns]
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Connection is closed immediately without any response.
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+	n, err := conn.Read(make([]byte, 1))
+	assert.Zero(t, n)
+	assert.Error(t, err)
+	assert.Equal(t, before+1, GetIPLimitRejections()[IPLimitMaxConns])
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	tplSlowConsumerMetricKey = "server.outbound.slow_consumer.%s"
+)
+
+// Slow-consumer policies once the outbound queue of the session is full. Only
+// pushes are dropped, and the session is disconnected if a response would be.
+const (
+	SlowConsumerDropOldest = "drop_oldest" // Drop the oldest queued push to queue the new message
+	SlowConsumerDropNewest = "drop_newest" // Drop the new push
+	SlowConsumerDisconnect = "disconnect"  // Disconnect the session
+)
+
+var (
+	// SlowConsumerPolicies lists all the slow-consumer policies.
+	SlowConsumerPolicies = []string{
+		SlowConsumerDropOldest, SlowConsumerDropNewest, SlowConsumerDisconnect,
+	}
+
+	errSlowConsumer = errors.New("slow consumer disconnected")
+)
+
+// OutboundOption bounds the outbound messages of each session, so that a client
+// which stops reading can't stall the handlers or hold unbounded memory.
+type OutboundOption struct {
+	// Max number of queued outbound messages, defaults to 256.
+	QueueSize int
+	// Max duration to write a message, 0 means unlimited.
+	WriteTimeout time.Duration
+	// Slow-consumer policy once the queue is full, defaults to disconnect.
+	Policy string
+}
+
+func (o OutboundOption) queueSize() int {
+	if o.QueueSize > 0 {
+		return o.QueueSize
+	}
+	return defaultOutboundQueueSize
+}
+
+func (o OutboundOption) policy() string {
+	if len(o.Policy) > 0 {
+		return o.Policy
+	}
+	return SlowConsumerDisconnect
+}
+
+// GetSlowConsumerCounts returns the number of times each slow-consumer policy
+// applied, ie., the dropped messages or the disconnected sessions.
+func GetSlowConsumerCounts() map[string]int64 {
+	counts := make(map[string]int64, len(SlowConsumerPolicies))
+	for _, p := range SlowConsumerPolicies {
+		counts[p] = metrics.GetOrRegisterCounter(slowConsumerMetricKey(p), nil).Count()
+	}
+
+	return counts
+}
+
+func slowConsumerMetricKey(policy string) string {
+	return fmt.Sprintf(tplSlowConsumerMetricKey, policy)
+}
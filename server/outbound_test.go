@@ -0,0 +1,90 @@
+package server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+func TestSlowConsumerPolicy(t *testing.T) {
+	newMessage := func(seq uint64) *proto.Message {
+		return &proto.Message{Seq: seq}
+	}
+
+	// The writer isn't started, so the queue is never consumed.
+	newSlowSession := func(policy string) (*Session, net.Conn) {
+		conn, peer := net.Pipe()
+		t.Cleanup(func() { peer.Close() })
+
+		s := newBufferedSession(conn, proto.NewCodec(), OutboundOption{QueueSize: 2, Policy: policy})
+		require.NoError(t, s.Push(newMessage(1)))
+		require.NoError(t, s.Send(newMessage(2)))
+		return s, peer
+	}
+
+	t.Run("DropOldest", func(t *testing.T) {
+		s, peer := newSlowSession(SlowConsumerDropOldest)
+		require.NoError(t, s.Push(newMessage(3)))
+
+		assert.Equal(t, 2, s.OutboundDepth())
+		assert.EqualValues(t, 1, s.OutboundDrops())
+
+		// Response is never dropped.
+		assert.ErrorIs(t, s.Send(newMessage(4)), errSlowConsumer)
+		assert.EqualValues(t, 1, s.OutboundDrops())
+		assert.EqualValues(t, 3, (<-s.outbound).Seq)
+
+		_, err := peer.Read(make([]byte, 1))
+		assert.Error(t, err)
+	})
+
+	t.Run("DropNewest", func(t *testing.T) {
+		s, peer := newSlowSession(SlowConsumerDropNewest)
+		assert.ErrorIs(t, s.Push(newMessage(3)), errOutboundQueueFull)
+
+		assert.Equal(t, 2, s.OutboundDepth())
+		assert.EqualValues(t, 1, s.OutboundDrops())
+		assert.EqualValues(t, 1, (<-s.outbound).Seq)
+		assert.EqualValues(t, 2, (<-s.outbound).Seq)
+
+		// Response is never dropped.
+		require.NoError(t, s.Send(newMessage(4)))
+		require.NoError(t, s.Send(newMessage(5)))
+		assert.ErrorIs(t, s.Send(newMessage(6)), errSlowConsumer)
+		assert.EqualValues(t, 1, s.OutboundDrops())
+
+		_, err := peer.Read(make([]byte, 1))
+		assert.Error(t, err)
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		s, peer := newSlowSession(SlowConsumerDisconnect)
+		assert.ErrorIs(t, s.Send(newMessage(3)), errSlowConsumer)
+		assert.ErrorIs(t, s.Send(newMessage(4)), errSessionClosed)
+
+		_, err := peer.Read(make([]byte, 1))
+		assert.Error(t, err)
+	})
+}
+
+func TestOutboundWriteTimeout(t *testing.T) {
+	// Peer never reads, so the write blocks until the deadline.
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	s := newBufferedSession(conn, proto.NewCodec(), OutboundOption{WriteTimeout: 50 * time.Millisecond})
+	s.StartWriter()
+	require.NoError(t, s.Send(&proto.Message{Seq: 1}))
+
+	select {
+	case <-s.writerDone:
+	case <-time.After(3 * time.Second):
+		t.Fatal("writer not unblocked by write timeout")
+	}
+
+	assert.ErrorIs(t, s.Send(&proto.Message{Seq: 2}), errSessionClosed)
+}
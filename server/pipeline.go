@@ -0,0 +1,86 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+const (
+	defaultPipelineMaxInFlight = 16
+)
+
+// PipelineOption configures the pipelined request handling of a session.
+type PipelineOption struct {
+	// Whether to handle requests of the same session concurrently.
+	Enabled bool
+	// Max number of in-flight requests per session.
+	MaxInFlight int
+	// Message types which are handled in order, ie., the request waits until
+	// all the former requests are handled, and blocks all the latter requests
+	// until handled.
+	OrderedTypes map[proto.MessageType]bool
+}
+
+func (o PipelineOption) maxInFlight() int {
+	if o.MaxInFlight > 0 {
+		return o.MaxInFlight
+	}
+	return defaultPipelineMaxInFlight
+}
+
+// handlePipelined handles requests of the session in pipeline, which uses a
+// reader goroutine to decode requests, a bounded number of handler goroutines
+// to handle requests concurrently, while the responses are written by the
+// single writer goroutine of the session in serialized order.
+func (ch *ConnectionHandler) handlePipelined(logger *logrus.Entry, session *Session) {
+	var inflight sync.WaitGroup
+	defer inflight.Wait()
+
+	sem := make(chan struct{}, ch.Pipeline.maxInFlight())
+	respond := func(msg *proto.Message) {
+		if err := session.Send(ch.serve(session, msg)); err != nil {
+			logger.WithError(err).
+				Debug("Session failed to send response message")
+			return
+		}
+
+		session.Refresh()
+	}
+
+	for {
+		msg, err := ch.readMessage(session)
+		if err != nil {
+			logger.WithError(err).
+				Debug("Codec failed to decode proto message")
+			return
+		}
+
+		if isHeartbeat(msg) {
+			// Respond heartbeat inline without occupying any handler slot.
+			respond(msg)
+			continue
+		}
+
+		if ch.isOrdered(msg) {
+			// Fence all the former requests, and handle this one exclusively.
+			inflight.Wait()
+			respond(msg)
+			continue
+		}
+
+		// Acquire handler slot, which blocks reading once in-flight limit reached.
+		sem <- struct{}{}
+		inflight.Add(1)
+
+		go func() {
+			defer func() {
+				<-sem
+				inflight.Done()
+			}()
+
+			respond(msg)
+		}()
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/proto"
+	pbproto "google.golang.org/protobuf/proto"
+)
+
+// newPipelineServer serves with the pipeline enabled, where the nickname request
+// is slow to handle.
+func newPipelineServer(t *testing.T, orderedTypes ...proto.MessageType) net.Conn {
+	ch := newInfoConnectionHandler()
+	info := ch.Handler
+	ch.Handler = func(ctx context.Context, msg *Message) *Message {
+		if msg.GetRequest().GetGenerateRandomNickname() != nil {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return info(ctx, msg)
+	}
+
+	ch.Pipeline = PipelineOption{Enabled: true, OrderedTypes: map[proto.MessageType]bool{}}
+	for _, t := range orderedTypes {
+		ch.Pipeline.OrderedTypes[t] = true
+	}
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	t.Cleanup(func() { srv.Close() })
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+	return conn
+}
+
+// exchange sends the requests with sequence IDs from 1, and returns the sequence
+// IDs of the responses in the order received.
+func exchange(t *testing.T, conn net.Conn, reqs ...pbproto.Message) []uint64 {
+	codec := proto.NewCodec()
+	for i, req := range reqs {
+		msg, err := proto.NewRequestMessage(req)
+		require.NoError(t, err)
+
+		msg.Seq = uint64(i + 1)
+		require.NoError(t, codec.Encode(msg, conn))
+	}
+
+	var seqs []uint64
+	for range reqs {
+		resp, err := codec.Decode(conn)
+		require.NoError(t, err)
+		seqs = append(seqs, resp.Seq)
+	}
+
+	return seqs
+}
+
+func TestPipeline(t *testing.T) {
+	conn := newPipelineServer(t)
+
+	// Fast request is not blocked by the slow one ahead.
+	seqs := exchange(t, conn, &proto.GenerateRandomNicknameRequest{}, &proto.InfoRequest{})
+	assert.Equal(t, []uint64{2, 1}, seqs)
+}
+
+func TestPipelineOrderedTypes(t *testing.T) {
+	conn := newPipelineServer(t, proto.MessageType_LOGIN)
+
+	// Ordered request waits for the former ones, and blocks the latter ones.
+	seqs := exchange(t, conn,
+		&proto.GenerateRandomNicknameRequest{}, &proto.LoginRequest{}, &proto.InfoRequest{},
+	)
+	assert.Equal(t, []uint64{1, 2, 3}, seqs)
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wanliqun/cgo-game-server/util"
+)
+
+const (
+	defaultProxyHeaderTimeout = 5 * time.Second
+
+	proxyV1Prefix       = "PROXY "
+	proxyV1MaxLength    = 107 // Including the trailing CRLF
+	proxyV2HeaderLength = 16
+	proxyV2AddrLenIPv4  = 12
+	proxyV2AddrLenIPv6  = 36
+)
+
+var (
+	proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	errInvalidProxyHeader = errors.New("invalid PROXY protocol header")
+)
+
+// ProxyProtocolOption configures the PROXY protocol v1/v2 header sent by load
+// balancer ahead of the connection data, which carries the real client address.
+type ProxyProtocolOption struct {
+	// Only connections from the trusted sources are parsed for the header, which
+	// is optional, so that health checks without the header are still served.
+	TrustedCIDRs util.CIDRSet
+	// Max duration to read the header.
+	HeaderTimeout time.Duration
+}
+
+func (o ProxyProtocolOption) headerTimeout() time.Duration {
+	if o.HeaderTimeout > 0 {
+		return o.HeaderTimeout
+	}
+	return defaultProxyHeaderTimeout
+}
+
+// proxyListener accepts connections with PROXY protocol header from trusted sources.
+type proxyListener struct {
+	net.Listener
+	opt ProxyProtocolOption
+}
+
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if !l.opt.TrustedCIDRs.ContainsAddr(conn.RemoteAddr()) {
+		return conn, nil
+	}
+
+	return &proxyConn{
+		Conn:    conn,
+		r:       bufio.NewReaderSize(conn, defaultReadBufferSize),
+		timeout: l.opt.headerTimeout(),
+	}, nil
+}
+
+// proxyConn parses the PROXY protocol header lazily once read or the remote
+// address requested, so as not to block the accept loop.
+type proxyConn struct {
+	net.Conn
+	r          *bufio.Reader
+	timeout    time.Duration
+	once       sync.Once
+	remoteAddr net.Addr // Real client address, or the peer address if not proxied
+	err        error    // Error to parse the header
+}
+
+func (c *proxyConn) init() {
+	c.once.Do(func() {
+		c.remoteAddr = c.Conn.RemoteAddr()
+
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		defer c.Conn.SetReadDeadline(time.Time{})
+
+		if addr, err := c.readHeader(); err != nil {
+			c.err = err
+		} else if addr != nil {
+			c.remoteAddr = addr
+		}
+	})
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	if c.init(); c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(b)
+}
+
+func (c *proxyConn) ReadByte() (byte, error) {
+	if c.init(); c.err != nil {
+		return 0, c.err
+	}
+	return c.r.ReadByte()
+}
+
+func (c *proxyConn) ReadSlice(delim byte) ([]byte, error) {
+	if c.init(); c.err != nil {
+		return nil, c.err
+	}
+	return c.r.ReadSlice(delim)
+}
+
+// RemoteAddr returns the real client address carried by the header.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.init()
+	return c.remoteAddr
+}
+
+// ProxyAddr returns the address of the proxy, ie., the peer address.
+func (c *proxyConn) ProxyAddr() net.Addr {
+	return c.Conn.RemoteAddr()
+}
+
+// asProxyConn unwraps the PROXY protocol connection, including the one under TLS.
+func asProxyConn(conn net.Conn) (*proxyConn, bool) {
+	if tc, ok := conn.(*tls.Conn); ok {
+		conn = tc.NetConn()
+	}
+
+	pc, ok := conn.(*proxyConn)
+	return pc, ok
+}
+
+// readHeader reads the header if any, and returns the real client address, or
+// nil if not proxied, eg., v1 `UNKNOWN` or v2 `LOCAL` command.
+func (c *proxyConn) readHeader() (net.Addr, error) {
+	// Neither protocol frames nor TLS records start with the first byte of the
+	// signatures, so peek it first not to block on short data.
+	b, err := c.r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b[0] {
+	case proxyV1Prefix[0]:
+		if b, err = c.r.Peek(len(proxyV1Prefix)); err == nil && string(b) == proxyV1Prefix {
+			return c.readHeaderV1()
+		}
+	case proxyV2Signature[0]:
+		if b, err = c.r.Peek(len(proxyV2Signature)); err == nil && bytes.Equal(b, proxyV2Signature) {
+			return c.readHeaderV2()
+		}
+	}
+
+	return nil, nil
+}
+
+// readHeaderV1 reads the human-readable header, eg.,
+// "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n".
+func (c *proxyConn) readHeaderV1() (net.Addr, error) {
+	line, err := c.r.ReadSlice('\n')
+	if err != nil || len(line) > proxyV1MaxLength || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.WithMessage(errInvalidProxyHeader, "malformed v1 header line")
+	}
+
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errors.WithMessage(errInvalidProxyHeader, "malformed v1 header fields")
+	}
+
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil {
+		return nil, errors.WithMessage(errInvalidProxyHeader, "malformed v1 source address")
+	}
+
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readHeaderV2 reads the binary header, whose TLVs are skipped.
+func (c *proxyConn) readHeaderV2() (net.Addr, error) {
+	var header [proxyV2HeaderLength]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return nil, err
+	}
+
+	verCmd, family := header[12], header[13]
+	if verCmd>>4 != 2 {
+		return nil, errors.WithMessagef(errInvalidProxyHeader, "unsupported v2 version %d", verCmd>>4)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return nil, err
+	}
+
+	switch verCmd & 0x0F {
+	case 0x00: // LOCAL, eg., health check from the proxy itself
+		return nil, nil
+	case 0x01: // PROXY
+	default:
+		return nil, errors.WithMessagef(errInvalidProxyHeader, "unsupported v2 command %d", verCmd&0x0F)
+	}
+
+	switch family >> 4 {
+	case 0x1: // AF_INET
+		if len(payload) < proxyV2AddrLenIPv4 {
+			return nil, errors.WithMessage(errInvalidProxyHeader, "short v2 IPv4 addresses")
+		}
+		ip := net.IP(append([]byte(nil), payload[:4]...))
+		return &net.TCPAddr{IP: ip, Port: int(binary.BigEndian.Uint16(payload[8:]))}, nil
+	case 0x2: // AF_INET6
+		if len(payload) < proxyV2AddrLenIPv6 {
+			return nil, errors.WithMessage(errInvalidProxyHeader, "short v2 IPv6 addresses")
+		}
+		ip := net.IP(append([]byte(nil), payload[:16]...))
+		return &net.TCPAddr{IP: ip, Port: int(binary.BigEndian.Uint16(payload[32:]))}, nil
+	default: // AF_UNSPEC or AF_UNIX
+		return nil, nil
+	}
+}
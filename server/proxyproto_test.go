@@ -0,0 +1,106 @@
+package server
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/util"
+)
+
+func proxyV2Header(ip net.IP, port uint16) []byte {
+	header := append([]byte(nil), proxyV2Signature...)
+	header = append(header, 0x21, 0x11) // Version 2 with PROXY command, TCP over IPv4
+	header = binary.BigEndian.AppendUint16(header, proxyV2AddrLenIPv4)
+	header = append(header, ip.To4()...)
+	header = append(header, 127, 0, 0, 1)
+	header = binary.BigEndian.AppendUint16(header, port)
+	return binary.BigEndian.AppendUint16(header, 8765)
+}
+
+func TestProxyProtocol(t *testing.T) {
+	testCases := []struct {
+		name     string
+		trusted  string
+		header   []byte
+		expected string // Expected remote address, empty for the peer address
+	}{
+		{"V1", "127.0.0.0/8", []byte("PROXY TCP4 1.2.3.4 127.0.0.1 5678 8765\r\n"), "1.2.3.4:5678"},
+		{"V1Unknown", "127.0.0.0/8", []byte("PROXY UNKNOWN\r\n"), ""},
+		{"V2", "127.0.0.0/8", proxyV2Header(net.IPv4(5, 6, 7, 8), 1234), "5.6.7.8:1234"},
+		{"NoHeader", "127.0.0.0/8", nil, ""},
+		{"Untrusted", "10.0.0.0/8", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cidrs, err := util.ParseCIDRs([]string{tc.trusted})
+			require.NoError(t, err)
+
+			ch := newInfoConnectionHandler()
+			srv, err := NewTCPServer(
+				"127.0.0.1:0", ch, nil, &ProxyProtocolOption{TrustedCIDRs: cidrs},
+			)
+			require.NoError(t, err)
+
+			go srv.Serve()
+			defer srv.Close()
+
+			conn, err := net.Dial("tcp", srv.listener.Addr().String())
+			require.NoError(t, err)
+			defer conn.Close()
+
+			conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+			codec := proto.NewCodec()
+			msg, err := proto.NewRequestMessage(&proto.InfoRequest{})
+			require.NoError(t, err)
+
+			_, err = conn.Write(tc.header)
+			require.NoError(t, err)
+			require.NoError(t, codec.Encode(msg, conn))
+
+			resp, err := codec.Decode(conn)
+			require.NoError(t, err)
+			assert.Equal(t, "test", resp.GetResponse().GetInfo().GetServerName())
+
+			expected := tc.expected
+			if len(expected) == 0 {
+				expected = conn.LocalAddr().String()
+			}
+
+			sessions := ch.SessManager.ListAll()
+			require.Len(t, sessions, 1)
+			assert.Equal(t, expected, sessions[0].RemoteAddr().String())
+		})
+	}
+}
+
+func TestProxyProtocolMalformed(t *testing.T) {
+	cidrs, err := util.ParseCIDRs([]string{"127.0.0.1"})
+	require.NoError(t, err)
+
+	srv, err := NewTCPServer(
+		"127.0.0.1:0", newInfoConnectionHandler(), nil, &ProxyProtocolOption{TrustedCIDRs: cidrs},
+	)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+	_, err = conn.Write([]byte("PROXY TCP4 not-an-ip 127.0.0.1 5678 8765\r\n"))
+	require.NoError(t, err)
+
+	// Connection is closed once the header is malformed.
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,357 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultReactorWorkersPerCPU = 8
+	reactorMaxEvents            = 128
+)
+
+var (
+	errReactorNotSupported = errors.New("reactor only supported on linux")
+
+	// errFrameIncomplete is returned by the reactor decoder if the frame is not
+	// completely buffered yet.
+	errFrameIncomplete = errors.New("frame incomplete")
+
+	readBufferPool = sync.Pool{
+		New: func() any {
+			buf := make([]byte, 0, defaultReadBufferSize)
+			return &buf
+		},
+	}
+)
+
+// ReactorOption configures the event loop of TCP connections.
+type ReactorOption struct {
+	// Number of workers handling the readable connections, defaults to 8 per CPU.
+	Workers int
+}
+
+func (o ReactorOption) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0) * defaultReactorWorkersPerCPU
+}
+
+// reactorConn is the connection registered to the reactor, which is handled by
+// the workers once readable, and deregistered before closed.
+type reactorConn struct {
+	net.Conn
+	reactor    *Reactor
+	fd         int
+	raw        syscall.RawConn
+	id         uint32 // Registration ID, in case the fd reused once closed
+	ch         *ConnectionHandler
+	session    *Session
+	decoder    *reactorDecoder
+	logger     *logrus.Entry
+	handshaked bool
+	done       func() // Called once closed
+	closeOnce  sync.Once
+}
+
+func (c *reactorConn) Close() (err error) {
+	err = net.ErrClosed
+	c.closeOnce.Do(func() {
+		c.reactor.remove(c)
+		err = c.Conn.Close()
+		c.done()
+
+		// Closed by the session itself, eg., write failure, is not noticed by any
+		// reader, so the session is terminated as well if not yet.
+		go c.ch.SessManager.Terminate(c.session)
+	})
+
+	return err
+}
+
+// handleReadable handles the messages buffered from the readable connection, and
+// returns false once the connection should be closed. Partial frame is kept by the
+// decoder until readable again instead of blocking the worker.
+func (ch *ConnectionHandler) handleReadable(c *reactorConn) bool {
+	session, decoder := c.session, c.decoder
+	if err := decoder.fill(); err != nil {
+		c.logger.WithError(err).Debug("Connection failed to read")
+		return false
+	}
+
+	for decoder.buffered() > 0 || decoder.eof {
+		msg, err := ch.readMessage(session)
+		if errors.Is(err, errFrameIncomplete) {
+			return decoder.suspend()
+		}
+
+		if err != nil {
+			c.logger.WithError(err).
+				Debug("Codec failed to decode proto message")
+			return false
+		}
+
+		if !c.handshaked {
+			if !ch.handleHandshake(c.logger, session, msg) {
+				return false
+			}
+			c.handshaked = true
+			continue
+		}
+
+		if err := session.Send(ch.serve(session, msg)); err != nil {
+			c.logger.WithError(err).
+				Debug("Session failed to send response message")
+			return false
+		}
+
+		session.Refresh()
+	}
+
+	decoder.release()
+	return true
+}
+
+// serve handles the readable connection, and re-arms the connection for the next
+// readiness if still open.
+func (r *Reactor) serve(c *reactorConn) {
+	if !c.ch.handleReadable(c) {
+		c.ch.SessManager.Terminate(c.session)
+		c.logger.Debug("Connection terminated")
+		return
+	}
+
+	r.rearm(c)
+}
+
+// expire closes the connection whose partial frame is not read in time.
+func (c *reactorConn) expire(reason string) {
+	c.ch.violate(c.session, &FrameViolationError{Reason: reason, Err: os.ErrDeadlineExceeded})
+	c.ch.SessManager.Terminate(c.session)
+	c.logger.Debug("Connection terminated")
+}
+
+// reactorDecoder reads the frames from the data buffered by each dispatch of the
+// readable connection. Unlike `frameReader`, it never waits for more data, but
+// fails with `errFrameIncomplete` so that the partial frame is decoded again once
+// readable, while the frame read timeouts are enforced by a timer meanwhile.
+type reactorDecoder struct {
+	conn     *reactorConn
+	opt      FrameOption
+	buf      []byte // Buffered data from pool, nil if none
+	off      int    // Offset of the data to read
+	frame    int    // Offset of the frame being decoded
+	eof      bool   // Whether the connection is closed by peer
+	resumed  bool   // Whether decoding the partial frame suspended before
+	started  bool   // Whether the first byte of the frame arrived
+	deadline time.Time
+	reason   string
+	timer    *time.Timer // Expires the suspended partial frame
+}
+
+func (d *reactorDecoder) buffered() int {
+	return len(d.buf) - d.off
+}
+
+// fill reads the data available, which is appended to the partial frame if any.
+func (d *reactorDecoder) fill() error {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	if d.buf == nil {
+		d.buf = (*readBufferPool.Get().(*[]byte))[:0]
+	}
+
+	// Move the partial frame ahead, and grow the buffer if full, eg., large frame.
+	n := copy(d.buf, d.buf[d.off:])
+	d.buf, d.off, d.frame = d.buf[:n], 0, 0
+	if n == cap(d.buf) {
+		d.buf = append(make([]byte, 0, 2*cap(d.buf)), d.buf...)
+	}
+
+	n, err := d.conn.readAvailable(d.buf[len(d.buf):cap(d.buf)])
+	d.buf = d.buf[:len(d.buf)+n]
+	if err == io.EOF {
+		d.eof = true
+		return nil
+	}
+
+	return err
+}
+
+// suspend leaves the partial frame to be decoded once readable again, and returns
+// false if the frame is already not read in time.
+func (d *reactorDecoder) suspend() bool {
+	d.off = d.frame
+	if d.buffered() == 0 {
+		d.release()
+		return true
+	}
+
+	d.resumed = true
+
+	if d.deadline.IsZero() {
+		return true
+	}
+
+	timeout, reason := time.Until(d.deadline), d.reason
+	if timeout <= 0 {
+		d.conn.ch.violate(d.conn.session, &FrameViolationError{
+			Reason: reason, Err: os.ErrDeadlineExceeded,
+		})
+		return false
+	}
+
+	d.timer = time.AfterFunc(timeout, func() { d.conn.expire(reason) })
+	return true
+}
+
+// release returns the buffer to pool once all data decoded, so that idle
+// connections hold no buffer.
+func (d *reactorDecoder) release() {
+	if d.buf == nil || d.buffered() > 0 {
+		return
+	}
+
+	if cap(d.buf) == defaultReadBufferSize {
+		buf := d.buf[:0]
+		readBufferPool.Put(&buf)
+	}
+	d.buf, d.off, d.frame = nil, 0, 0
+}
+
+func (d *reactorDecoder) reset() {
+	d.frame = d.off
+	if d.resumed {
+		d.resumed = false
+		return
+	}
+
+	d.started, d.deadline, d.reason = false, time.Time{}, ""
+}
+
+func (d *reactorDecoder) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if d.buffered() == 0 {
+		return 0, d.errNoData()
+	}
+
+	n := copy(p, d.buf[d.off:])
+	d.onRead(n)
+	return n, nil
+}
+
+func (d *reactorDecoder) ReadByte() (byte, error) {
+	if d.buffered() == 0 {
+		return 0, d.errNoData()
+	}
+
+	b := d.buf[d.off]
+	d.onRead(1)
+	return b, nil
+}
+
+// ReadSlice reads until the delimiter for line-delimited codecs, whose line is
+// limited by the read buffer size as `bufio.Reader` does.
+func (d *reactorDecoder) ReadSlice(delim byte) ([]byte, error) {
+	data := d.buf[d.off:]
+	if i := bytes.IndexByte(data, delim); i >= 0 {
+		d.onRead(i + 1)
+		return data[:i+1], nil
+	}
+
+	if len(data) >= defaultReadBufferSize {
+		d.onRead(len(data))
+		return data, bufio.ErrBufferFull
+	}
+
+	if d.eof {
+		d.onRead(len(data))
+		return data, io.EOF
+	}
+
+	return nil, errFrameIncomplete
+}
+
+// OnFrameHeader enforces the frame size limit, and records the deadline of the
+// frame data.
+func (d *reactorDecoder) OnFrameHeader(size int) error {
+	if d.opt.MaxSize > 0 && size > d.opt.MaxSize {
+		return &FrameViolationError{
+			Reason: FrameViolationTooLarge,
+			Err:    errors.Errorf("frame length %d exceeds limit %d", size, d.opt.MaxSize),
+		}
+	}
+
+	if d.opt.HeaderToBodyTimeout > 0 {
+		d.arm(time.Now().Add(d.opt.HeaderToBodyTimeout), FrameViolationBodyTimeout)
+	}
+
+	return nil
+}
+
+func (d *reactorDecoder) errNoData() error {
+	if d.eof {
+		return io.EOF
+	}
+	return errFrameIncomplete
+}
+
+func (d *reactorDecoder) onRead(n int) {
+	d.off += n
+	if n > 0 && !d.started {
+		d.started = true
+
+		if d.opt.ReadTimeout > 0 {
+			d.arm(time.Now().Add(d.opt.ReadTimeout), FrameViolationReadTimeout)
+		}
+	}
+}
+
+// arm records the deadline unless an earlier one already recorded.
+func (d *reactorDecoder) arm(deadline time.Time, reason string) {
+	if d.deadline.IsZero() || deadline.Before(d.deadline) {
+		d.deadline, d.reason = deadline, reason
+	}
+}
+
+// newReactorConn wraps the connection of the file descriptor with a session,
+// whose writer goroutine is started on demand.
+func (r *Reactor) newReactorConn(
+	ch *ConnectionHandler, l net.Listener, conn net.Conn, raw syscall.RawConn, fd int, done func(),
+) *reactorConn {
+	c := &reactorConn{
+		Conn: conn, reactor: r, fd: fd, raw: raw, ch: ch, done: done,
+		handshaked: !ch.Handshake.Required,
+	}
+
+	c.session = newOnDemandSession(c, ch.Codec, ch.Outbound)
+	c.session.datagrams = ch.Datagram
+	c.decoder = &reactorDecoder{conn: c, opt: ch.Frame}
+	c.session.reader = c.decoder
+
+	c.logger = logrus.WithFields(logrus.Fields{
+		"protocol":   l.Addr().Network(),
+		"listenAddr": l.Addr(),
+		"remoteAddr": c.session.RemoteAddr(),
+		"reactor":    true,
+	})
+
+	return c
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"io"
+	"net"
+	"sync"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const reactorEvents = syscall.EPOLLIN | syscall.EPOLLRDHUP | syscall.EPOLLONESHOT
+
+// Reactor polls the readiness of idle TCP connections with epoll, and dispatches
+// the readable ones to a bounded worker pool running the same handler chain, so
+// that idle connections hold neither goroutines nor buffers.
+type Reactor struct {
+	epfd    int
+	wakeFds [2]int // Pipe to wake up the poller on close
+	tasks   chan *reactorConn
+
+	mu     sync.Mutex
+	conns  map[int]*reactorConn // Registered fd => connection
+	nextID uint32
+	closed bool
+
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewReactor(opt ReactorOption) (*Reactor, error) {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create epoll")
+	}
+
+	r := &Reactor{
+		epfd:  epfd,
+		tasks: make(chan *reactorConn, reactorMaxEvents),
+		conns: make(map[int]*reactorConn),
+	}
+
+	if err := syscall.Pipe2(r.wakeFds[:], syscall.O_CLOEXEC|syscall.O_NONBLOCK); err != nil {
+		syscall.Close(epfd)
+		return nil, errors.WithMessage(err, "failed to create wake-up pipe")
+	}
+
+	ev := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(r.wakeFds[0])}
+	if err := syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, r.wakeFds[0], &ev); err != nil {
+		r.closeFds()
+		return nil, errors.WithMessage(err, "failed to register wake-up pipe")
+	}
+
+	workers := opt.workers()
+	r.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go r.work()
+	}
+
+	go r.poll()
+
+	return r, nil
+}
+
+// register registers the connection to be handled once readable, and returns
+// false if not supported, eg., TLS connection or pipelined handling, which is left
+// to a goroutine.
+func (r *Reactor) register(ch *ConnectionHandler, l net.Listener, conn net.Conn, done func()) bool {
+	if ch.Pipeline.Enabled {
+		return false
+	}
+
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return false
+	}
+
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	fd := -1
+	raw.Control(func(f uintptr) { fd = int(f) })
+	if fd < 0 {
+		return false
+	}
+
+	c := r.newReactorConn(ch, l, conn, raw, fd, done)
+
+	// Session is managed before registered, since it may be readable at once.
+	ch.SessManager.Add(c.session)
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		ch.SessManager.remove(c.session.ID)
+		return false
+	}
+
+	r.nextID++
+	c.id = r.nextID
+	r.conns[fd] = c
+
+	ev := syscall.EpollEvent{Events: reactorEvents, Fd: int32(fd), Pad: int32(c.id)}
+	err = syscall.EpollCtl(r.epfd, syscall.EPOLL_CTL_ADD, fd, &ev)
+	if err != nil {
+		delete(r.conns, fd)
+	}
+	r.mu.Unlock()
+
+	if err != nil {
+		c.logger.WithError(err).Info("Reactor failed to register connection")
+		ch.SessManager.Terminate(c.session)
+		return true
+	}
+
+	c.logger.Debug("New connection established")
+	return true
+}
+
+// readAvailable reads the data available without waiting for readiness, which
+// returns zero if none available, or `io.EOF` once closed by peer.
+func (c *reactorConn) readAvailable(p []byte) (n int, err error) {
+	rerr := c.raw.Read(func(fd uintptr) bool {
+		n, err = syscall.Read(int(fd), p)
+		return true
+	})
+
+	switch {
+	case rerr != nil:
+		return 0, rerr
+	case err == syscall.EAGAIN || err == syscall.EINTR:
+		return 0, nil
+	case err != nil:
+		return 0, err
+	case n == 0:
+		return 0, io.EOF
+	}
+
+	return n, nil
+}
+
+// rearm re-arms the one-shot readiness of the connection if still registered.
+func (r *Reactor) rearm(c *reactorConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed || r.conns[c.fd] != c {
+		return
+	}
+
+	ev := syscall.EpollEvent{Events: reactorEvents, Fd: int32(c.fd), Pad: int32(c.id)}
+	if err := syscall.EpollCtl(r.epfd, syscall.EPOLL_CTL_MOD, c.fd, &ev); err != nil {
+		c.logger.WithError(err).Debug("Reactor failed to re-arm connection")
+	}
+}
+
+// remove deregisters the connection, which must be done before the fd closed.
+func (r *Reactor) remove(c *reactorConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed || r.conns[c.fd] != c {
+		return
+	}
+
+	delete(r.conns, c.fd)
+	syscall.EpollCtl(r.epfd, syscall.EPOLL_CTL_DEL, c.fd, nil)
+}
+
+func (r *Reactor) lookup(ev *syscall.EpollEvent) *reactorConn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c := r.conns[int(ev.Fd)]; c != nil && c.id == uint32(ev.Pad) {
+		return c
+	}
+
+	return nil
+}
+
+func (r *Reactor) poll() {
+	defer close(r.tasks)
+
+	events := make([]syscall.EpollEvent, reactorMaxEvents)
+	for {
+		n, err := syscall.EpollWait(r.epfd, events, -1)
+		if err == syscall.EINTR {
+			continue
+		}
+
+		if err != nil {
+			logrus.WithError(err).Error("Reactor failed to poll connections")
+			return
+		}
+
+		for i := range events[:n] {
+			if int(events[i].Fd) == r.wakeFds[0] {
+				return
+			}
+
+			if c := r.lookup(&events[i]); c != nil {
+				r.tasks <- c
+			}
+		}
+	}
+}
+
+func (r *Reactor) work() {
+	defer r.wg.Done()
+
+	for c := range r.tasks {
+		r.serve(c)
+	}
+}
+
+// Close stops polling once the queued connections handled, while the registered
+// connections are left to be closed by the session manager.
+func (r *Reactor) Close() error {
+	r.closeOnce.Do(func() {
+		syscall.Write(r.wakeFds[1], []byte{1})
+		r.wg.Wait()
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.closed = true
+		r.closeFds()
+	})
+
+	return nil
+}
+
+func (r *Reactor) closeFds() {
+	syscall.Close(r.wakeFds[0])
+	syscall.Close(r.wakeFds[1])
+	syscall.Close(r.epfd)
+}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/client"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+const soakIdleConns = 2000
+
+func newReactorServer(t testing.TB, workers int, frame FrameOption) (*Server, *Reactor) {
+	r, err := NewReactor(ReactorOption{Workers: workers})
+	require.NoError(t, err)
+
+	ch := newInfoConnectionHandler()
+	ch.Handshake = HandshakeOption{Required: true}
+	ch.Frame = frame
+	ch.Reactor = r
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	return srv, r
+}
+
+func TestReactor(t *testing.T) {
+	srv, r := newReactorServer(t, 4, FrameOption{})
+	defer r.Close()
+	defer srv.Close()
+
+	addr := srv.listener.Addr().String()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			c := client.NewTCPClient(addr)
+			if !assert.NoError(t, c.Connect()) {
+				return
+			}
+			defer c.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+
+			for j := 0; j < 10; j++ {
+				resp, err := c.Call(ctx, &proto.InfoRequest{})
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.Equal(t, "test", resp.GetResponse().GetInfo().GetServerName())
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Closed connections are deregistered and released.
+	assert.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(r.conns) == 0 && srv.SessManager.Count() == 0
+	}, 3*time.Second, 10*time.Millisecond)
+	assert.Zero(t, srv.Admission.Count())
+}
+
+func TestReactorPartialFrame(t *testing.T) {
+	srv, r := newReactorServer(t, 1, FrameOption{})
+	defer r.Close()
+	defer srv.Close()
+
+	addr := srv.listener.Addr().String()
+
+	msg, err := proto.NewRequestMessage(&proto.InfoRequest{})
+	require.NoError(t, err)
+
+	frame := bytes.NewBuffer(nil)
+	require.NoError(t, proto.NewCodec().Encode(msg, frame))
+
+	// Trickling client holds no worker while its frame is partial.
+	slow := dialIdle(t, addr)
+	defer slow.Close()
+
+	_, err = slow.Write(frame.Bytes()[:2])
+	require.NoError(t, err)
+
+	c := client.NewTCPClient(addr)
+	require.NoError(t, c.Connect())
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = c.Call(ctx, &proto.InfoRequest{})
+	require.NoError(t, err)
+
+	// Frame is decoded once completed across several reads.
+	for _, b := range frame.Bytes()[2:] {
+		_, err = slow.Write([]byte{b})
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+
+	slow.SetReadDeadline(time.Now().Add(3 * time.Second))
+	resp, err := proto.NewCodec().Decode(slow)
+	require.NoError(t, err)
+	assert.Equal(t, "test", resp.GetResponse().GetInfo().GetServerName())
+}
+
+func TestReactorPartialFrameTimeout(t *testing.T) {
+	srv, r := newReactorServer(t, 1, FrameOption{ReadTimeout: 200 * time.Millisecond})
+	defer r.Close()
+	defer srv.Close()
+
+	before := GetFrameViolationCounts()[FrameViolationReadTimeout]
+
+	conn := dialIdle(t, srv.listener.Addr().String())
+	defer conn.Close()
+
+	// Partial frame is expired even though no more data arrives.
+	_, err := conn.Write([]byte{0, 0})
+	require.NoError(t, err)
+
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	resp, err := proto.NewCodec().Decode(conn)
+	require.NoError(t, err)
+	assert.Equal(t, StatusBadRequest, resp.GetResponse().GetStatus().GetCode())
+
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+	assert.EqualValues(t, 1, GetFrameViolationCounts()[FrameViolationReadTimeout]-before)
+}
+
+// dialIdle establishes the connection which stays idle after the handshake.
+func dialIdle(tb testing.TB, addr string) net.Conn {
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(tb, err)
+
+	msg, err := proto.NewRequestMessage(&proto.HelloRequest{ProtocolVersion: proto.ProtocolVersion})
+	require.NoError(tb, err)
+
+	codec := proto.NewCodec()
+	require.NoError(tb, codec.Encode(msg, conn))
+
+	_, err = codec.Decode(conn)
+	require.NoError(tb, err)
+
+	return conn
+}
+
+func inuseMemory() uint64 {
+	runtime.GC()
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapInuse + ms.StackInuse
+}
+
+// BenchmarkIdleConnMemory soaks idle connections to compare the memory per
+// connection of the reactor against goroutine per connection, including the
+// client side of the connections, eg.,
+//
+//	go test -run ^$ -bench IdleConnMemory -benchtime 1x ./server
+func BenchmarkIdleConnMemory(b *testing.B) {
+	soak := func(b *testing.B, srv *Server) {
+		addr := srv.listener.Addr().String()
+
+		for i := 0; i < b.N; i++ {
+			before := inuseMemory()
+
+			conns := make([]net.Conn, 0, soakIdleConns)
+			for j := 0; j < soakIdleConns; j++ {
+				conns = append(conns, dialIdle(b, addr))
+			}
+
+			after := inuseMemory()
+			b.ReportMetric(float64(after-before)/soakIdleConns, "B/conn")
+
+			for _, conn := range conns {
+				conn.Close()
+			}
+
+			require.Eventually(b, func() bool {
+				return srv.SessManager.Count() == 0
+			}, 10*time.Second, 10*time.Millisecond)
+		}
+	}
+
+	b.Run("Goroutine", func(b *testing.B) {
+		ch := newInfoConnectionHandler()
+		ch.Handshake = HandshakeOption{Required: true}
+
+		srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+		require.NoError(b, err)
+
+		go srv.Serve()
+		defer srv.Close()
+
+		soak(b, srv)
+	})
+
+	b.Run("Reactor", func(b *testing.B) {
+		srv, r := newReactorServer(b, 4, FrameOption{})
+		defer r.Close()
+		defer srv.Close()
+
+		soak(b, srv)
+	})
+}
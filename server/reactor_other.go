@@ -0,0 +1,29 @@
+//go:build !linux
+
+package server
+
+import "net"
+
+// Reactor polls the readiness of idle TCP connections, which is only supported
+// on linux.
+type Reactor struct{}
+
+func NewReactor(opt ReactorOption) (*Reactor, error) {
+	return nil, errReactorNotSupported
+}
+
+func (r *Reactor) register(ch *ConnectionHandler, l net.Listener, conn net.Conn, done func()) bool {
+	return false
+}
+
+func (c *reactorConn) readAvailable(p []byte) (int, error) {
+	return 0, errReactorNotSupported
+}
+
+func (r *Reactor) rearm(c *reactorConn) {}
+
+func (r *Reactor) remove(c *reactorConn) {}
+
+func (r *Reactor) Close() error {
+	return nil
+}
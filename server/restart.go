@@ -0,0 +1,81 @@
+package server
+
+import (
+	"net"
+	"sync"
+)
+
+// pendingListener defers listening until released, eg., the socket inherited on
+// hot restart, which is read by the previous process until it exits, so that the
+// datagrams of its KCP sessions are not taken by this process.
+type pendingListener struct {
+	addr    net.Addr
+	release <-chan struct{}
+	listen  func() (net.Listener, error)
+	closing chan struct{}
+
+	mu     sync.Mutex
+	l      net.Listener // Listener once released
+	err    error        // Error to listen once released
+	closed bool
+}
+
+func newPendingListener(
+	addr net.Addr, release <-chan struct{}, listen func() (net.Listener, error)) *pendingListener {
+	return &pendingListener{
+		addr: addr, release: release, listen: listen, closing: make(chan struct{}),
+	}
+}
+
+func (p *pendingListener) Accept() (net.Conn, error) {
+	l, err := p.wait()
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Accept()
+}
+
+// wait blocks until released, and then listens only once.
+func (p *pendingListener) wait() (net.Listener, error) {
+	select {
+	case <-p.release:
+	case <-p.closing:
+		return nil, net.ErrClosed
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, net.ErrClosed
+	}
+
+	if p.l == nil && p.err == nil {
+		p.l, p.err = p.listen()
+	}
+
+	return p.l, p.err
+}
+
+func (p *pendingListener) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+
+	p.closed = true
+	close(p.closing)
+
+	if p.l != nil {
+		return p.l.Close()
+	}
+
+	return nil
+}
+
+func (p *pendingListener) Addr() net.Addr {
+	return p.addr
+}
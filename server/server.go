@@ -2,23 +2,29 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"io"
 	"net"
 	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
 	"github.com/sirupsen/logrus"
 	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/util"
 	"github.com/xtaci/kcp-go/v5"
 )
 
 const (
 	defaultShutdownTimeout = 5 * time.Second
+	defaultRefuseTimeout   = 3 * time.Second
 )
 
 const (
 	ServerStatusInitial int32 = iota
 	ServerStatusStarted
+	ServerStatusDraining
 	ServerStatusStopped
 )
 
@@ -28,27 +34,58 @@ var (
 )
 
 type Server struct {
-	*ConnectionHandler              // Connection handler
-	listener           net.Listener // Net listener
-	status             atomic.Int32 // Server status
+	*ConnectionHandler                 // Connection handler
+	listener           net.Listener    // Net listener
+	kcpOption          *util.KCPOption // KCP session tuning, only for UDP server
+	socket             io.Closer       // UDP socket, which is not closed by KCP listener
+	status             atomic.Int32    // Server status
 }
 
-func NewTCPServer(addr string, ch *ConnectionHandler) (srv *Server, err error) {
-	l, err := net.Listen("tcp", addr)
+// NewTCPServer creates TCP server, which serves over TLS if tlsConf is not nil,
+// and parses PROXY protocol header from trusted sources if proxy is not nil.
+func NewTCPServer(
+	addr string, ch *ConnectionHandler, tlsConf *tls.Config, proxy *ProxyProtocolOption) (srv *Server, err error) {
+	l, err := util.ListenTCP(addr)
 	if err != nil {
 		return nil, err
 	}
 
+	if proxy != nil {
+		// PROXY protocol header precedes the TLS handshake.
+		l = &proxyListener{Listener: l, opt: *proxy}
+	}
+
+	if tlsConf != nil {
+		l = tls.NewListener(l, tlsConf)
+	}
+
 	return &Server{ConnectionHandler: ch, listener: l}, nil
 }
 
-func NewUDPServer(addr string, ch *ConnectionHandler) (srv *Server, err error) {
-	l, err := kcp.Listen(addr)
+// NewUDPServer creates KCP server, whose sessions are encrypted if block is not nil,
+// and tuned by the KCP option.
+func NewUDPServer(
+	addr string, ch *ConnectionHandler, block kcp.BlockCrypt, opt util.KCPOption) (srv *Server, err error) {
+	conn, inherited, err := util.ListenUDP(addr)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Server{ConnectionHandler: ch, listener: l}, nil
+	listen := func() (net.Listener, error) {
+		return kcp.ServeConn(block, opt.DataShards, opt.ParityShards, conn)
+	}
+
+	var l net.Listener
+	if inherited {
+		// Inherited socket is still read by the previous process on hot restart,
+		// which refuses new KCP connections until it finishes draining.
+		l = newPendingListener(conn.LocalAddr(), util.Released(), listen)
+	} else if l, err = listen(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &Server{ConnectionHandler: ch, listener: l, kcpOption: &opt, socket: conn}, nil
 }
 
 // Serve always returns a non-nil error and closes l.
@@ -68,16 +105,33 @@ func (srv *Server) Serve() error {
 	})
 	logger.Info("Server listened endpoint started serving")
 
+	network := srv.listener.Addr().Network()
+
 	defer srv.listener.Close()
 	for {
-		// TODO: Enforce max connections capacity in case of server overload.
 		conn, err := srv.listener.Accept()
 		if err == nil {
-			go srv.Handle(srv.listener, conn)
+			if sess, ok := conn.(*kcp.UDPSession); ok && srv.kcpOption != nil {
+				srv.kcpOption.Apply(sess)
+			}
+
+			if srv.status.Load() == ServerStatusDraining {
+				srv.refuse(conn, ErrServerDraining)
+				continue
+			}
+
+			if _, ok := asProxyConn(conn); ok {
+				// Real client address is only known once the PROXY protocol
+				// header read, which shouldn't block the accept loop.
+				go srv.admit(network, conn)
+			} else {
+				srv.admit(network, conn)
+			}
 			continue
 		}
 
-		if srv.status.Load() == ServerStatusStopped {
+		if status := srv.status.Load(); status == ServerStatusDraining ||
+			status == ServerStatusStopped {
 			return errServerClosed
 		}
 
@@ -86,17 +140,100 @@ func (srv *Server) Serve() error {
 	}
 }
 
+// admit enforces the per-IP limits and the max connections capacity, and then
+// handles the admitted connection by the reactor or in a new goroutine.
+func (srv *Server) admit(network string, conn net.Conn) {
+	var ip net.IP
+	if srv.IPLimiter != nil {
+		ip = addrIP(conn.RemoteAddr())
+	}
+
+	if ip != nil {
+		if violation, ok := srv.IPLimiter.Acquire(ip); !ok {
+			logrus.WithFields(logrus.Fields{
+				"protocol":   network,
+				"remoteAddr": conn.RemoteAddr(),
+				"violation":  violation,
+			}).Debug("Connection closed by per-IP limit")
+
+			conn.Close()
+			return
+		}
+	}
+
+	release := func() {
+		if ip != nil {
+			srv.IPLimiter.Release(ip)
+		}
+	}
+
+	// Enforce max connections capacity in case of server overload.
+	if err := srv.Admission.Admit(network); err != nil {
+		release()
+		srv.refuse(conn, err)
+		return
+	}
+
+	done := func() {
+		srv.Admission.Release(network)
+		release()
+	}
+
+	// Handled by the reactor workers once readable if supported.
+	if srv.Reactor != nil && srv.Reactor.register(srv.ConnectionHandler, srv.listener, conn, done) {
+		return
+	}
+
+	go func() {
+		defer done()
+		srv.Handle(srv.listener, conn)
+	}()
+}
+
+// refuse responds the refused connection in a new goroutine, or simply closes it
+// once too many refusals in progress, so that refusals are bounded on overload.
+func (srv *Server) refuse(conn net.Conn, err error) {
+	if !srv.Admission.acquireRefusal() {
+		conn.Close()
+		return
+	}
+
+	go func() {
+		defer srv.Admission.releaseRefusal()
+		srv.Refuse(conn, err)
+	}()
+}
+
+// Drain stops accepting new connections while the established ones are kept.
+func (srv *Server) Drain() error {
+	if !srv.status.CompareAndSwap(ServerStatusStarted, ServerStatusDraining) {
+		return nil
+	}
+
+	// KCP sessions share the underlying UDP socket with the listener, so the
+	// listener is kept open to refuse new connections with draining status.
+	if srv.socket == nil {
+		return srv.listener.Close()
+	}
+
+	return nil
+}
+
 func (srv *Server) Close() error {
 	if srv.status.Load() == ServerStatusStopped {
 		return errServerClosed
 	}
 
-	if !srv.status.CompareAndSwap(ServerStatusStarted, ServerStatusStopped) {
+	if !srv.status.CompareAndSwap(ServerStatusStarted, ServerStatusStopped) &&
+		!srv.status.CompareAndSwap(ServerStatusDraining, ServerStatusStopped) {
 		return nil
 	}
 
 	// Close listener
 	srv.listener.Close()
+	if srv.socket != nil {
+		srv.socket.Close()
+	}
 
 	// Terminate all connections.
 	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
@@ -106,50 +243,174 @@ func (srv *Server) Close() error {
 }
 
 type ConnectionHandler struct {
-	Handler     HandlerFunc     // Connection handler
-	SessManager *SessionManager // Session manager
-	Codec       *proto.Codec    // Protocol codec
+	Handler     HandlerFunc          // Connection handler
+	SessManager *SessionManager      // Session manager
+	Admission   *AdmissionController // Connection admission controller
+	Drainer     *Drainer             // Drain coordinator before shutdown
+	Codec       proto.MessageCodec   // Protocol codec
+	Pipeline    PipelineOption       // Pipelined request handling option
+	Heartbeat   HeartbeatOption      // Heartbeat liveness policy
+	Compression CompressionOption    // Per-frame compression negotiated by client
+	Handshake   HandshakeOption      // HELLO handshake on every connection
+	Frame       FrameOption          // Frame decoding limits
+	Batch       BatchOption          // Batch envelope carrying several messages
+	IPLimiter   *IPLimiter           // Per-IP connection limits, nil if unlimited
+	Reactor     *Reactor             // Event loop of TCP connections, nil for goroutine per connection
+	Outbound    OutboundOption       // Outbound queue bound and slow-consumer policy per session
+	Datagram    *DatagramHub         // Unreliable datagram channels bound to sessions, nil if disabled
 }
 
 func NewConnectionHandler(
-	h HandlerFunc, mgr *SessionManager, codec *proto.Codec) *ConnectionHandler {
+	h HandlerFunc, mgr *SessionManager,
+	ac *AdmissionController, drainer *Drainer, codec proto.MessageCodec) *ConnectionHandler {
 	return &ConnectionHandler{
-		Handler: h, SessManager: mgr, Codec: codec,
+		Handler: h, SessManager: mgr, Admission: ac, Drainer: drainer, Codec: codec,
+	}
+}
+
+// WithCodec returns a copy of the connection handler with the specified codec,
+// so that listeners of different wire formats share the same handler chain.
+func (ch *ConnectionHandler) WithCodec(codec proto.MessageCodec) *ConnectionHandler {
+	c := *ch
+	c.Codec = codec
+	return &c
+}
+
+// Refuse responds the refused connection with the error status before closing it.
+func (ch *ConnectionHandler) Refuse(conn net.Conn, err error) {
+	defer conn.Close()
+
+	logger := logrus.WithFields(logrus.Fields{
+		"protocol":   conn.LocalAddr().Network(),
+		"remoteAddr": conn.RemoteAddr(),
+	})
+	logger.WithError(err).Debug("Connection refused")
+
+	conn.SetWriteDeadline(time.Now().Add(defaultRefuseTimeout))
+
+	resp := NewMessageWithError(err).ProtoMessage()
+	if err := ch.Codec.Encode(resp, conn); err != nil {
+		logger.WithError(err).
+			Debug("Codec failed to encode proto message")
 	}
 }
 
 // handleConnection handles new accepted connection from net listener.
 func (ch *ConnectionHandler) Handle(l net.Listener, conn net.Conn) {
+	session := newBufferedSession(conn, ch.Codec, ch.Outbound)
+	session.datagrams = ch.Datagram
+
 	logger := logrus.WithFields(logrus.Fields{
 		"protocol":   l.Addr().Network(),
 		"listenAddr": l.Addr(),
-		"remoteAddr": conn.RemoteAddr(),
+		"remoteAddr": session.RemoteAddr(),
 	})
+	if pc, ok := asProxyConn(conn); ok {
+		logger = logger.WithField("proxyAddr", pc.ProxyAddr())
+	}
 	logger.Debug("New connection established")
 
-	session := NewSession(conn)
+	session.reader = newFrameDecoder(conn, ch.Frame)
+	session.StartWriter()
 	ch.SessManager.Add(session)
 	defer ch.SessManager.Terminate(session)
 
+	if ch.Handshake.Required && !ch.handshake(logger, session) {
+		return
+	}
+
+	if ch.Pipeline.Enabled {
+		ch.handlePipelined(logger, session)
+	} else {
+		ch.handleSerial(logger, session)
+	}
+
+	logger.Debug("Connection termiated")
+}
+
+// handleSerial decodes, handles and responds messages one at a time.
+func (ch *ConnectionHandler) handleSerial(logger *logrus.Entry, session *Session) {
 	for {
-		msg, err := ch.Codec.Decode(conn)
+		msg, err := ch.readMessage(session)
 		if err != nil {
 			logger.WithError(err).
 				Debug("Codec failed to decode proto message")
-			break
+			return
 		}
 
-		ctx := NewContextFromSession(context.Background(), session)
-		resp := ch.Handler(ctx, NewMessage(msg))
-
-		if err := ch.Codec.Encode(resp.ProtoMessage(), conn); err != nil {
+		if err := session.Send(ch.serve(session, msg)); err != nil {
 			logger.WithError(err).
-				Debug("Codec failed to encode proto message")
-			break
+				Debug("Session failed to send response message")
+			return
 		}
 
 		session.Refresh()
 	}
+}
 
-	logger.Debug("Connection termiated")
+// readMessage decodes the next message of the session. Malformed message is
+// responded with bad request status instead of closing the session, while
+// frame violation closes the session.
+func (ch *ConnectionHandler) readMessage(session *Session) (*proto.Message, error) {
+	for {
+		session.reader.reset()
+		msg, err := session.Codec().Decode(session.reader)
+
+		if fe := asFrameViolation(err); fe != nil {
+			ch.violate(session, fe)
+			return nil, fe
+		}
+
+		var merr *proto.MalformedError
+		if !errors.As(err, &merr) {
+			return msg, err
+		}
+
+		resp := NewMessageWithError(NewBadRequestError(merr)).ProtoMessage()
+		if err := session.Send(resp); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// violate counts the frame violation, which closes the session.
+func (ch *ConnectionHandler) violate(session *Session, fe *FrameViolationError) {
+	metrics.GetOrRegisterCounter(frameViolationMetricKey(fe.Reason), nil).Inc(1)
+
+	// Tell the client the disconnect reason if still writable.
+	session.Push(NewMessageWithError(NewBadRequestError(fe)).ProtoMessage())
+}
+
+// serve handles the request message through the handler chain, and returns
+// the response message.
+func (ch *ConnectionHandler) serve(session *Session, msg *proto.Message) *proto.Message {
+	if isHeartbeat(msg) {
+		// Heartbeat bypasses the handler chain, eg., authentication and metrics.
+		return ch.pong(msg)
+	}
+
+	if isNegotiation(msg) {
+		return ch.negotiate(session, msg)
+	}
+
+	if isHello(msg) {
+		resp, _ := ch.hello(session, msg)
+		return resp
+	}
+
+	if isBatch(msg) {
+		return ch.serveBatch(session, msg)
+	}
+
+	// Track in-flight request, so that it can finish before shutdown.
+	defer ch.Drainer.track()()
+
+	ctx := NewContextFromSession(context.Background(), session)
+	resp := ch.Handler(ctx, NewMessage(msg))
+
+	// Echo the sequence ID to correlate response with the request.
+	respMsg := resp.ProtoMessage()
+	respMsg.Seq = msg.Seq
+
+	return respMsg
 }
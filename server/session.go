@@ -1,7 +1,10 @@
 package server
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"io"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -9,17 +12,32 @@ import (
 
 	"github.com/badu/bus"
 	"github.com/google/uuid"
+	"github.com/rcrowley/go-metrics"
 	"github.com/sirupsen/logrus"
+	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/util"
 	"go.uber.org/multierr"
 )
 
 const (
 	timeoutCheckInterval = time.Second
-	timeOutDuration      = time.Second * 30
+
+	defaultOutboundQueueSize = 256
+	defaultFlushTimeout      = 3 * time.Second
+	defaultWriteBufferSize   = 4096
 
 	CtxKeySession ContextKey = "session"
 )
 
+var (
+	errSessionClosed     = errors.New("session closed")
+	errOutboundQueueFull = errors.New("outbound queue full")
+
+	writeBufferPool = sync.Pool{
+		New: func() any { return bufio.NewWriterSize(nil, defaultWriteBufferSize) },
+	}
+)
+
 func NewContextFromSession(parent context.Context, sess *Session) context.Context {
 	return context.WithValue(parent, CtxKeySession, sess)
 }
@@ -30,85 +48,524 @@ func SessionFromContext(ctx context.Context) (sess *Session, ok bool) {
 }
 
 type Session struct {
-	ID         string   // Session ID
-	Conn       net.Conn // Underlying network connection
-	lastActive int64    // Last active timestamp
+	ID         string                          // Session ID
+	Conn       net.Conn                        // Underlying network connection
+	remoteAddr net.Addr                        // Real client address, eg., carried by PROXY protocol
+	codec      atomic.Value                    // Protocol codec to encode outbound messages
+	reader     frameDecoder                    // Reader of inbound frames
+	writer     flushWriter                     // Writer of outbound frames
+	handshake  atomic.Pointer[Handshake]       // Client information exchanged by HELLO
+	outbound   chan outboundMessage            // Outbound message queue
+	outOption  OutboundOption                  // Outbound queue bound and slow-consumer policy
+	drops      atomic.Int64                    // Number of outbound messages dropped by slow-consumer policy
+	closing    chan struct{}                   // Closed once the session starts closing
+	closeOnce  sync.Once                       // Ensures closing only once
+	writing    atomic.Bool                     // Whether the writer goroutine started
+	onDemand   bool                            // Whether the writer goroutine only runs while messages queued
+	writeMu    sync.Mutex                      // Guards the writer of on-demand writer goroutines
+	writerDone chan struct{}                   // Closed once the writer goroutine exits
+	lastActive int64                           // Last active timestamp in unix nanoseconds
+	wheelSlot  int                             // Scheduled timing wheel slot, -1 if not scheduled
+	datagrams  *DatagramHub                    // Hub of the datagram channels, nil if disabled
+	datagram   atomic.Pointer[datagramChannel] // Datagram channel bound to the session, nil if not bound
+}
+
+func NewSession(conn net.Conn, codec proto.MessageCodec) *Session {
+	return newBufferedSession(conn, codec, OutboundOption{})
+}
+
+// newBufferedSession creates the session whose writer goroutine always runs with
+// its own write buffer.
+func newBufferedSession(conn net.Conn, codec proto.MessageCodec, opt OutboundOption) *Session {
+	s := newSession(conn, codec, opt)
+
+	if _, ok := conn.(messageConn); ok {
+		// Frames are written directly to message-oriented connection, so that
+		// each frame is carried by a single message.
+		s.writer = directWriter{conn}
+	} else {
+		s.writer = bufio.NewWriterSize(conn, defaultWriteBufferSize)
+	}
+
+	return s
 }
 
-func NewSession(conn net.Conn) *Session {
-	return &Session{
+// newOnDemandSession creates the session whose writer goroutine is started on
+// demand with a pooled write buffer, so that idle sessions hold neither.
+func newOnDemandSession(conn net.Conn, codec proto.MessageCodec, opt OutboundOption) *Session {
+	s := newSession(conn, codec, opt)
+	s.onDemand = true
+	return s
+}
+
+func newSession(conn net.Conn, codec proto.MessageCodec, opt OutboundOption) *Session {
+	s := &Session{
 		ID:         uuid.NewString(),
 		Conn:       conn,
-		lastActive: time.Now().Unix(),
+		outbound:   make(chan outboundMessage, opt.queueSize()),
+		outOption:  opt,
+		closing:    make(chan struct{}),
+		writerDone: make(chan struct{}),
+		lastActive: time.Now().UnixNano(),
+		wheelSlot:  -1,
+	}
+
+	if conn != nil {
+		s.remoteAddr = conn.RemoteAddr()
+	}
+
+	s.SetCodec(codec)
+	return s
+}
+
+// messageConn is the message-oriented connection, eg., WebSocket, which carries
+// each write as a single message.
+type messageConn interface {
+	net.Conn
+	messageOriented()
+}
+
+// flushWriter writes outbound frames, which should be flushed explicitly.
+type flushWriter interface {
+	io.Writer
+	Flush() error
+}
+
+// directWriter writes directly without buffering.
+type directWriter struct {
+	io.Writer
+}
+
+func (directWriter) Flush() error { return nil }
+
+// SetCodec replaces the codec to encode the outbound messages and decode the
+// inbound ones, eg., with the negotiated compression.
+func (s *Session) SetCodec(codec proto.MessageCodec) {
+	s.codec.Store(sessionCodec{codec})
+}
+
+// Codec returns the codec to encode the outbound messages and decode the inbound
+// ones.
+func (s *Session) Codec() proto.MessageCodec {
+	return s.codec.Load().(sessionCodec).MessageCodec
+}
+
+// RemoteAddr returns the real client address, which is carried by PROXY protocol
+// header if behind load balancer.
+func (s *Session) RemoteAddr() net.Addr {
+	return s.remoteAddr
+}
+
+// SetHandshake records the client information exchanged by HELLO handshake.
+func (s *Session) SetHandshake(hs *Handshake) {
+	s.handshake.Store(hs)
+}
+
+// Handshake returns the client information exchanged by HELLO handshake, or
+// nil if not handshaked yet.
+func (s *Session) Handshake() *Handshake {
+	return s.handshake.Load()
+}
+
+// HasCapability checks if the capability is supported by both client and server.
+func (s *Session) HasCapability(capability string) bool {
+	if hs := s.Handshake(); hs != nil {
+		return hs.Capabilities[capability]
+	}
+	return false
+}
+
+// sessionCodec boxes the codec to be stored in atomic value, since the codecs
+// are of different concrete types.
+type sessionCodec struct {
+	proto.MessageCodec
+}
+
+// outboundMessage is the queued outbound message, which is either a response or
+// an unsolicited push.
+type outboundMessage struct {
+	*proto.Message
+	push bool
+}
+
+// Send queues the response message to be written to the underlying connection
+// without blocking. Responses are never dropped, so the session is disconnected
+// if the queue is full and no push can be dropped instead.
+func (s *Session) Send(msg *proto.Message) error {
+	return s.enqueue(outboundMessage{Message: msg})
+}
+
+// Push queues the unsolicited message to be written to the underlying connection
+// without blocking, and applies the slow-consumer policy if the queue is full.
+func (s *Session) Push(msg *proto.Message) error {
+	return s.enqueue(outboundMessage{Message: msg, push: true})
+}
+
+func (s *Session) enqueue(msg outboundMessage) error {
+	select {
+	case <-s.closing:
+		return errSessionClosed
+	default:
+	}
+
+	for {
+		select {
+		case s.outbound <- msg:
+			s.wake()
+			return nil
+		default:
+		}
+
+		// Drop policies only apply to pushes, since the dropped response would
+		// leave the request unanswered.
+		switch policy := s.outOption.policy(); {
+		case policy == SlowConsumerDropOldest:
+			// Retry once the oldest dropped, unless taken by the writer meanwhile.
+			select {
+			case oldest := <-s.outbound:
+				if !oldest.push {
+					return s.disconnect()
+				}
+				s.drop(policy)
+			default:
+			}
+		case policy == SlowConsumerDropNewest && msg.push:
+			s.drop(policy)
+			return errOutboundQueueFull
+		default:
+			return s.disconnect()
+		}
+	}
+}
+
+// disconnect disconnects the slow consumer.
+func (s *Session) disconnect() error {
+	metrics.GetOrRegisterCounter(slowConsumerMetricKey(SlowConsumerDisconnect), nil).Inc(1)
+	logrus.WithField("remoteAddr", s.RemoteAddr()).
+		Debug("Session disconnected due to slow consumer")
+
+	// Stop queuing any more message, and unblock the reader.
+	s.closeOnce.Do(func() { close(s.closing) })
+	if s.Conn != nil {
+		s.Conn.Close()
+	}
+	return errSlowConsumer
+}
+
+func (s *Session) drop(policy string) {
+	s.drops.Add(1)
+	metrics.GetOrRegisterCounter(slowConsumerMetricKey(policy), nil).Inc(1)
+}
+
+// OutboundDepth returns the number of queued outbound messages.
+func (s *Session) OutboundDepth() int {
+	return len(s.outbound)
+}
+
+// OutboundCapacity returns the max number of queued outbound messages.
+func (s *Session) OutboundCapacity() int {
+	return cap(s.outbound)
+}
+
+// OutboundDrops returns the number of outbound messages dropped by slow-consumer
+// policy.
+func (s *Session) OutboundDrops() int64 {
+	return s.drops.Load()
+}
+
+// BindDatagram binds the unreliable datagram channel to the session, and returns
+// the token to be carried by the datagrams of client, the secret key to
+// authenticate the datagrams of both sides, along with the endpoint of the
+// channel. Binding again replaces the token and key.
+func (s *Session) BindDatagram() (token, key []byte, endpoint string, err error) {
+	if s.datagrams == nil {
+		return nil, nil, "", errDatagramNotEnabled
+	}
+
+	c, err := s.datagrams.bind(s)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	if old := s.datagram.Swap(c); old != nil {
+		old.hub.unbind(old)
+	}
+
+	// Unbind if closed meanwhile, since the session may have unbound before.
+	select {
+	case <-s.closing:
+		s.unbindDatagram()
+		return nil, nil, "", errSessionClosed
+	default:
+	}
+
+	return []byte(c.token), c.key, s.datagrams.endpoint, nil
+}
+
+// SendDatagram sends the message over the bound datagram channel without any
+// delivery guarantee, which fails if the client address not learned yet.
+func (s *Session) SendDatagram(msg *proto.Message) error {
+	c := s.datagram.Load()
+	if c == nil {
+		return errDatagramNotBound
+	}
+
+	return c.send(msg)
+}
+
+func (s *Session) unbindDatagram() {
+	if c := s.datagram.Swap(nil); c != nil {
+		c.hub.unbind(c)
+	}
+}
+
+// StartWriter starts the single writer goroutine to write queued messages.
+func (s *Session) StartWriter() {
+	if s.writing.CompareAndSwap(false, true) {
+		go s.write()
+	}
+}
+
+func (s *Session) write() {
+	defer close(s.writerDone)
+
+	for {
+		select {
+		case msg := <-s.outbound:
+			if err := s.writeMessage(msg.Message); err != nil {
+				logrus.WithField("remoteAddr", s.RemoteAddr()).
+					WithError(err).
+					Debug("Session failed to write proto message")
+
+				// Stop queuing any more message, and unblock the reader.
+				s.closeOnce.Do(func() { close(s.closing) })
+				s.Conn.Close()
+				return
+			}
+		case <-s.closing:
+			s.flush()
+			return
+		}
+	}
+}
+
+// wake starts the on-demand writer goroutine if not running.
+func (s *Session) wake() {
+	if s.onDemand && s.writing.CompareAndSwap(false, true) {
+		go s.writeOnDemand()
+	}
+}
+
+// writeOnDemand writes the queued messages with a pooled write buffer, and exits
+// once no more queued.
+func (s *Session) writeOnDemand() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	bw := writeBufferPool.Get().(*bufio.Writer)
+	bw.Reset(s.Conn)
+	s.writer = bw
+
+	defer func() {
+		s.writer = nil
+		bw.Reset(nil)
+		writeBufferPool.Put(bw)
+	}()
+
+	for {
+		select {
+		case msg := <-s.outbound:
+			if err := s.writeMessage(msg.Message); err != nil {
+				logrus.WithField("remoteAddr", s.RemoteAddr()).
+					WithError(err).
+					Debug("Session failed to write proto message")
+
+				s.closeOnce.Do(func() { close(s.closing) })
+				s.Conn.Close()
+				return
+			}
+		default:
+			s.writing.Store(false)
+
+			// Message queued before the flag cleared is left to this goroutine.
+			if len(s.outbound) == 0 || !s.writing.CompareAndSwap(false, true) {
+				return
+			}
+		}
+	}
+}
+
+// writeMessage encodes the message into the write buffer, which is flushed
+// once no more queued messages, so that bursts are coalesced into fewer writes.
+func (s *Session) writeMessage(msg *proto.Message) error {
+	if timeout := s.outOption.WriteTimeout; timeout > 0 {
+		s.Conn.SetWriteDeadline(time.Now().Add(timeout))
+	}
+
+	if err := s.Codec().Encode(msg, s.writer); err != nil {
+		return err
+	}
+
+	if len(s.outbound) == 0 {
+		return s.writer.Flush()
+	}
+
+	return nil
+}
+
+// flush writes out all the queued messages before the session closes.
+func (s *Session) flush() {
+	for {
+		select {
+		case msg := <-s.outbound:
+			if err := s.Codec().Encode(msg.Message, s.writer); err != nil {
+				return
+			}
+		default:
+			s.writer.Flush()
+			return
+		}
 	}
 }
 
 func (s *Session) Refresh() {
-	atomic.StoreInt64(&s.lastActive, time.Now().Unix())
+	atomic.StoreInt64(&s.lastActive, time.Now().UnixNano())
 }
 
 func (s *Session) LastActive() time.Time {
-	return time.Unix(atomic.LoadInt64(&s.lastActive), 0)
+	return time.Unix(0, atomic.LoadInt64(&s.lastActive))
 }
 
+// deadline returns the expiry deadline in unix nanoseconds.
+func (s *Session) deadline(timeout time.Duration) int64 {
+	return atomic.LoadInt64(&s.lastActive) + int64(timeout)
+}
+
+// Close closes the session after the queued messages are flushed or
+// the flush timeout elapsed.
 func (s *Session) Close() error {
-	if s.Conn != nil {
-		return s.Conn.Close()
+	s.closeOnce.Do(func() { close(s.closing) })
+	s.unbindDatagram()
+
+	if s.Conn == nil {
+		return nil
 	}
-	return nil
+
+	if s.onDemand {
+		s.Conn.SetWriteDeadline(time.Now().Add(defaultFlushTimeout))
+
+		// Wait for the running writer goroutine, and then flush the rest directly.
+		s.writeMu.Lock()
+		s.writer = directWriter{s.Conn}
+		s.flush()
+		s.writer = nil
+		s.writeMu.Unlock()
+	} else if s.writing.Load() {
+		s.Conn.SetWriteDeadline(time.Now().Add(defaultFlushTimeout))
+		<-s.writerDone
+	}
+
+	return s.Conn.Close()
 }
 
-type SessionManager struct {
+// sessionShard guards a partition of the sessions along with its own timing wheel.
+type sessionShard struct {
 	mu       sync.Mutex
 	sessions map[string]*Session
+	wheel    *timingWheel // Timing wheel to schedule session expiry
+}
+
+// SessionManager manages sessions in lock-striped shards by session ID.
+type SessionManager struct {
+	shards   []*sessionShard
+	count    atomic.Int64  // Number of sessions
+	timeout  time.Duration // Inactive duration before session terminated
 	stopChan chan struct{}
 }
 
-func NewSessionManager() *SessionManager {
-	return &SessionManager{
+func NewSessionManager(timeout time.Duration) *SessionManager {
+	if timeout <= 0 {
+		timeout = defaultHeartbeatTimeout
+	}
+
+	m := &SessionManager{
 		stopChan: make(chan struct{}),
-		sessions: make(map[string]*Session),
+		shards:   make([]*sessionShard, util.DefaultNumShards),
+		timeout:  timeout,
+	}
+
+	now := time.Now()
+	for i := range m.shards {
+		m.shards[i] = &sessionShard{
+			sessions: make(map[string]*Session),
+			wheel:    newTimingWheel(timeoutCheckInterval, timeout, now),
+		}
 	}
+
+	return m
+}
+
+func (m *SessionManager) shard(id string) *sessionShard {
+	return m.shards[util.ShardIndex(id, len(m.shards))]
 }
 
 func (m *SessionManager) Add(sess *Session) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	sh := m.shard(sess.ID)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
 
-	m.sessions[sess.ID] = sess
+	if _, ok := sh.sessions[sess.ID]; !ok {
+		m.count.Add(1)
+	}
+
+	sh.sessions[sess.ID] = sess
+	sh.wheel.add(sess)
 }
 
 func (m *SessionManager) Count() int {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	return len(m.sessions)
+	return int(m.count.Load())
 }
 
+// Terminate closes the session and publishes a session terminated event
+// if the session is still managed.
 func (m *SessionManager) Terminate(sess *Session) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	s, ok := m.remove(sess.ID)
+	if !ok {
+		return nil
+	}
+
+	err := s.Close()
 
-	if s, ok := m.sessions[sess.ID]; ok {
-		delete(m.sessions, sess.ID)
-		return s.Close()
+	// Publish session terminated event
+	bus.Pub(&SessionTerminatedEvent{Sess: s})
+	return err
+}
+
+func (m *SessionManager) remove(id string) (*Session, bool) {
+	sh := m.shard(id)
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	s, ok := sh.sessions[id]
+	if ok {
+		delete(sh.sessions, id)
+		sh.wheel.remove(s)
+		m.count.Add(-1)
 	}
 
-	return nil
+	return s, ok
 }
 
 func (m *SessionManager) TerminateAll(ctx context.Context) (err error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	for _, s := range m.sessions {
+	for _, s := range m.all() {
 		select {
 		case <-ctx.Done():
 			err = multierr.Append(err, ctx.Err())
 			return
 		default:
-			delete(m.sessions, s.ID)
-			err = multierr.Append(err, s.Close())
+			err = multierr.Append(err, m.Terminate(s))
 		}
 	}
 
@@ -119,12 +576,14 @@ func (m *SessionManager) ListAll() []*Session {
 	return m.all()
 }
 
-func (m *SessionManager) all() (res []*Session) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	for _, s := range m.sessions {
-		res = append(res, s)
+func (m *SessionManager) all() []*Session {
+	res := make([]*Session, 0, m.Count())
+	for _, sh := range m.shards {
+		sh.mu.Lock()
+		for _, s := range sh.sessions {
+			res = append(res, s)
+		}
+		sh.mu.Unlock()
 	}
 
 	return res
@@ -151,17 +610,24 @@ func (m *SessionManager) Stop() {
 }
 
 func (m *SessionManager) checkTimeout() {
-	for _, s := range m.all() {
-		// Check if the session has been inactive for longer than the timeout duration.
-		if time.Since(s.LastActive()) >= timeOutDuration {
-			logrus.WithFields(logrus.Fields{
-				"remoteAddr": s.Conn.RemoteAddr(),
-				"lastActive": s.LastActive(),
-			}).Debug("Terminate session due to timeout")
-
-			m.Terminate(s)
-			// Publish session terminated event
-			bus.Pub(&SessionTerminatedEvent{Sess: s})
-		}
+	now := time.Now()
+	for _, sh := range m.shards {
+		sh.mu.Lock()
+		// Only the sessions due on the timing wheel are checked.
+		expired := sh.wheel.advance(now)
+		sh.mu.Unlock()
+
+		m.terminateExpired(expired)
+	}
+}
+
+func (m *SessionManager) terminateExpired(expired []*Session) {
+	for _, s := range expired {
+		logrus.WithFields(logrus.Fields{
+			"remoteAddr": s.RemoteAddr(),
+			"lastActive": s.LastActive(),
+		}).Debug("Terminate session due to timeout")
+
+		m.Terminate(s)
 	}
 }
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/client"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+const (
+	benchNumSessions = 100_000
+)
+
+func TestSessionPush(t *testing.T) {
+	pushed := make(chan *Session, 1)
+
+	// Push the event ahead of the response.
+	ch := newInfoConnectionHandler()
+	info := ch.Handler
+	ch.Handler = func(ctx context.Context, msg *Message) *Message {
+		session, _ := SessionFromContext(ctx)
+
+		event, _ := proto.NewEventMessage(&proto.KickedEvent{Reason: "test"})
+		assert.NoError(t, session.Push(event))
+
+		pushed <- session
+		return info(ctx, msg)
+	}
+
+	srv, err := NewTCPServer("127.0.0.1:0", ch, nil, nil)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	c := client.NewTCPClient(srv.listener.Addr().String())
+	require.NoError(t, c.Connect())
+	defer c.Close()
+
+	events := make(chan *proto.Message, 1)
+	c.OnMessage(func(msg *proto.Message) {
+		if msg.GetEvent() != nil {
+			events <- msg
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err = c.Call(ctx, &proto.InfoRequest{})
+	require.NoError(t, err)
+
+	// Unsolicited message is delivered without sequence ID.
+	select {
+	case event := <-events:
+		assert.Equal(t, "test", event.GetEvent().GetKicked().GetReason())
+		assert.Zero(t, event.Seq)
+	case <-ctx.Done():
+		t.Fatal("pushed event not received")
+	}
+
+	// No more push once the session closed.
+	session := <-pushed
+	srv.SessManager.Terminate(session)
+	assert.ErrorIs(t, session.Push(&proto.Message{}), errSessionClosed)
+}
+
+func TestTimingWheelExpiry(t *testing.T) {
+	now := time.Now()
+	w := newTimingWheel(time.Second, 5*time.Second, now)
+
+	idle, active := NewSession(nil, nil), NewSession(nil, nil)
+	w.add(idle)
+	w.add(active)
+
+	// Nothing is due before the timeout.
+	assert.Empty(t, w.advance(now.Add(3*time.Second)))
+
+	// Only the idle session expires after the timeout.
+	atomic.StoreInt64(&active.lastActive, now.Add(3*time.Second).UnixNano())
+	expired := w.advance(now.Add(6 * time.Second))
+	assert.Equal(t, []*Session{idle}, expired)
+
+	// The active session is rescheduled and expires later.
+	assert.Empty(t, w.advance(now.Add(7*time.Second)))
+	expired = w.advance(now.Add(9 * time.Second))
+	assert.Equal(t, []*Session{active}, expired)
+
+	// Removed session never expires.
+	w.add(idle)
+	w.remove(idle)
+	assert.Empty(t, w.advance(now.Add(time.Minute)))
+}
+
+func newBenchSessionManager(b *testing.B) *SessionManager {
+	m := NewSessionManager(defaultHeartbeatTimeout)
+	for i := 0; i < benchNumSessions; i++ {
+		m.Add(NewSession(nil, nil))
+	}
+
+	b.ResetTimer()
+	return m
+}
+
+// checkTimeoutByScan is the former expiry check, which scans all the sessions
+// on every tick.
+func (m *SessionManager) checkTimeoutByScan() {
+	for _, s := range m.all() {
+		if time.Since(s.LastActive()) >= m.timeout {
+			m.Terminate(s)
+		}
+	}
+}
+
+func BenchmarkCheckTimeoutScan(b *testing.B) {
+	m := newBenchSessionManager(b)
+	for i := 0; i < b.N; i++ {
+		m.checkTimeoutByScan()
+	}
+}
+
+func BenchmarkCheckTimeoutTimingWheel(b *testing.B) {
+	m := newBenchSessionManager(b)
+
+	// Each iteration advances the wheel by one tick, ie., a timeout check interval.
+	now := time.Now()
+	for i := 0; i < b.N; i++ {
+		now = now.Add(timeoutCheckInterval)
+
+		// Keep all sessions alive, so that the due ones are rescheduled.
+		if i%int(defaultHeartbeatTimeout/timeoutCheckInterval) == 0 {
+			b.StopTimer()
+			for _, s := range m.all() {
+				atomic.StoreInt64(&s.lastActive, now.UnixNano())
+			}
+			b.StartTimer()
+		}
+
+		for _, sh := range m.shards {
+			sh.wheel.advance(now)
+		}
+	}
+}
+
+func BenchmarkSessionRefresh(b *testing.B) {
+	m := newBenchSessionManager(b)
+	sessions := m.all()
+
+	for i := 0; i < b.N; i++ {
+		sessions[i%len(sessions)].Refresh()
+	}
+}
+
+func BenchmarkSessionManagerAddTerminate(b *testing.B) {
+	m := NewSessionManager(defaultHeartbeatTimeout)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s := NewSession(nil, nil)
+			m.Add(s)
+			m.Count()
+			m.Terminate(s)
+		}
+	})
+}
@@ -6,4 +6,8 @@ const (
 	StatusOK StatusCode = iota
 	StatusInternalServerError
 	StatusBadRequest
+	StatusServerFull
+	StatusServerDraining
+	StatusHandshakeRequired
+	StatusUnsupportedProtocolVersion
 )
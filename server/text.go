@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bufio"
+	"net"
+
+	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/util"
+)
+
+const (
+	textReadBufferSize = 64 * 1024 // Also the max line length
+)
+
+// bufferedConn buffers reading, so that the text codec can read line by line.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (c *bufferedConn) ReadByte() (byte, error) {
+	return c.r.ReadByte()
+}
+
+func (c *bufferedConn) ReadSlice(delim byte) ([]byte, error) {
+	return c.r.ReadSlice(delim)
+}
+
+// textListener accepts connections with buffered reading.
+type textListener struct {
+	net.Listener
+}
+
+func (l *textListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &bufferedConn{Conn: conn, r: bufio.NewReaderSize(conn, textReadBufferSize)}, nil
+}
+
+// NewTextServer creates TCP server speaking line-delimited protojson text, eg.,
+// to drive the server with netcat. It shares the connection handler along with
+// the handler chain except for the codec, and HELLO handshake is optional.
+func NewTextServer(addr string, ch *ConnectionHandler) (*Server, error) {
+	l, err := util.ListenTCP(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	textHandler := ch.WithCodec(proto.NewJSONLineCodec())
+	textHandler.Handshake.Required = false
+
+	return &Server{
+		ConnectionHandler: textHandler,
+		listener:          &textListener{l},
+	}, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestTextServer(t *testing.T) {
+	// Handshake is not required to drive the server with netcat.
+	ch := newInfoConnectionHandler()
+	ch.Handshake.Required = true
+
+	srv, err := NewTextServer("127.0.0.1:0", ch)
+	require.NoError(t, err)
+
+	go srv.Serve()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(3 * time.Second))
+	_, err = conn.Write([]byte("\n{\"type\":\"INFO\",\"request\":{\"info\":{}},\"seq\":\"7\"}\n{malformed\n"))
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(conn)
+	readMessage := func() *proto.Message {
+		require.True(t, scanner.Scan())
+
+		msg := new(proto.Message)
+		require.NoError(t, protojson.Unmarshal(scanner.Bytes(), msg))
+		return msg
+	}
+
+	msg := readMessage()
+	assert.Equal(t, "test", msg.GetResponse().GetInfo().GetServerName())
+	assert.EqualValues(t, 7, msg.Seq)
+
+	// Malformed line is responded with bad request status, and the session is kept.
+	msg = readMessage()
+	assert.Equal(t, StatusBadRequest, msg.GetResponse().GetStatus().GetCode())
+
+	_, err = conn.Write([]byte("{\"type\":\"INFO\",\"request\":{\"info\":{}}}\n"))
+	require.NoError(t, err)
+	assert.NotNil(t, readMessage().GetResponse().GetInfo())
+}
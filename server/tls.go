@@ -0,0 +1,134 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCertReloadInterval = 10 * time.Second
+)
+
+// TLSOption configures TLS for the TCP listener.
+type TLSOption struct {
+	CertFile       string        // Path of the PEM encoded certificate
+	KeyFile        string        // Path of the PEM encoded private key
+	ClientCAFile   string        // Path of the PEM encoded CA to verify client certificates, if any
+	ReloadInterval time.Duration // Interval to check whether the certificate changed
+}
+
+// NewTLSConfig creates the TLS config, which reloads the certificate once the
+// certificate or key file changed.
+func NewTLSConfig(opt TLSOption) (*tls.Config, error) {
+	reloader, err := newCertReloader(opt.CertFile, opt.KeyFile, opt.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if len(opt.ClientCAFile) > 0 {
+		pem, err := os.ReadFile(opt.ClientCAFile)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to read client CA file")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no valid client CA certificate found")
+		}
+
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return conf, nil
+}
+
+// certReloader serves the certificate, and reloads it lazily during handshake
+// if the certificate or key file modified.
+type certReloader struct {
+	certFile, keyFile string
+	interval          time.Duration
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time // Latest modification time of the certificate and key files
+	checkedAt time.Time // Last time to check the modification
+}
+
+func newCertReloader(certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	if interval <= 0 {
+		interval = defaultCertReloadInterval
+	}
+
+	r := &certReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) >= r.interval {
+		if err := r.reload(); err != nil {
+			// Keep serving the old certificate until fixed.
+			logrus.WithError(err).Error("Failed to reload TLS certificate")
+		}
+	}
+
+	return r.cert, nil
+}
+
+func (r *certReloader) reload() error {
+	r.checkedAt = time.Now()
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	if r.cert != nil && !modTime.After(r.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.WithMessage(err, "failed to load certificate key pair")
+	}
+
+	if r.cert != nil {
+		logrus.WithField("certFile", r.certFile).Info("TLS certificate reloaded")
+	}
+
+	r.cert, r.modTime = &cert, modTime
+	return nil
+}
+
+func (r *certReloader) latestModTime() (t time.Time, err error) {
+	for _, f := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return t, errors.WithMessage(err, "failed to stat certificate file")
+		}
+
+		if fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+
+	return t, nil
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wanliqun/cgo-game-server/util"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	NetworkWebSocket = "ws"
+)
+
+// wsAddr is the network address of WebSocket endpoint.
+type wsAddr string
+
+func (a wsAddr) Network() string { return NetworkWebSocket }
+func (a wsAddr) String() string  { return string(a) }
+
+// wsConn adapts the WebSocket connection, which carries each length-prefixed
+// protobuf message as a single binary WebSocket message.
+type wsConn struct {
+	*websocket.Conn
+	localAddr  net.Addr
+	remoteAddr net.Addr
+	closeOnce  sync.Once
+	done       chan struct{} // Closed once the connection is closed
+}
+
+func (c *wsConn) messageOriented()     {}
+func (c *wsConn) LocalAddr() net.Addr  { return c.localAddr }
+func (c *wsConn) RemoteAddr() net.Addr { return c.remoteAddr }
+
+func (c *wsConn) Close() (err error) {
+	c.closeOnce.Do(func() {
+		err = c.Conn.Close()
+		close(c.done)
+	})
+
+	return err
+}
+
+// WebSocketListener accepts WebSocket connections upgraded from HTTP requests,
+// so that they can be served like the other stream connections. It is also an
+// HTTP handler, which can be mounted onto an HTTP router.
+type WebSocketListener struct {
+	addr      net.Addr
+	conns     chan net.Conn
+	closing   chan struct{}
+	closeOnce sync.Once
+	handler   websocket.Server
+	server    *http.Server // Own HTTP server, nil if mounted onto another router
+}
+
+// NewWebSocketListener creates the WebSocket listener to be mounted onto the
+// HTTP router serving at addr.
+func NewWebSocketListener(addr string) *WebSocketListener {
+	l := &WebSocketListener{
+		addr:    wsAddr(addr),
+		conns:   make(chan net.Conn),
+		closing: make(chan struct{}),
+	}
+
+	// Browsers from any origin are accepted, since clients are authenticated
+	// by the login request instead.
+	l.handler = websocket.Server{Handler: l.serveConn}
+	return l
+}
+
+// ListenWebSocket creates the WebSocket listener serving on its own endpoint.
+func ListenWebSocket(addr, path string) (*WebSocketListener, error) {
+	ln, err := util.ListenTCP(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	l := NewWebSocketListener(ln.Addr().String())
+
+	mux := http.NewServeMux()
+	mux.Handle(path, l)
+	l.server = &http.Server{Handler: mux, ReadHeaderTimeout: defaultRefuseTimeout}
+	go l.server.Serve(ln)
+
+	return l, nil
+}
+
+func (l *WebSocketListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-l.closing:
+		http.Error(w, ErrServerDraining.Error(), http.StatusServiceUnavailable)
+	default:
+		l.handler.ServeHTTP(w, r)
+	}
+}
+
+// serveConn hands over the upgraded connection to the acceptor, and blocks until
+// the connection closed, since the connection is closed once returned.
+func (l *WebSocketListener) serveConn(ws *websocket.Conn) {
+	ws.PayloadType = websocket.BinaryFrame
+	// Clear the deadlines inherited from the HTTP server.
+	ws.SetDeadline(time.Time{})
+
+	conn := &wsConn{
+		Conn:       ws,
+		localAddr:  l.addr,
+		remoteAddr: wsAddr(ws.Request().RemoteAddr),
+		done:       make(chan struct{}),
+	}
+
+	select {
+	case l.conns <- conn:
+		<-conn.done
+	case <-l.closing:
+	}
+}
+
+func (l *WebSocketListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closing:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops accepting new connections, while the accepted ones are kept.
+func (l *WebSocketListener) Close() (err error) {
+	l.closeOnce.Do(func() {
+		close(l.closing)
+		if l.server != nil {
+			// Hijacked WebSocket connections are not closed by the HTTP server.
+			err = l.server.Close()
+		}
+	})
+
+	return err
+}
+
+func (l *WebSocketListener) Addr() net.Addr {
+	return l.addr
+}
+
+// NewWebSocketServer creates WebSocket server accepting from the WebSocket listener.
+func NewWebSocketServer(l *WebSocketListener, ch *ConnectionHandler) *Server {
+	return &Server{ConnectionHandler: ch, listener: l}
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wanliqun/cgo-game-server/client"
+	"github.com/wanliqun/cgo-game-server/proto"
+)
+
+func newInfoConnectionHandler() *ConnectionHandler {
+	info := func(ctx context.Context, msg *Message) *Message {
+		resp, _ := proto.NewResponseMessage(&proto.InfoResponse{ServerName: "test"})
+		return NewMessage(resp)
+	}
+
+	return NewConnectionHandler(
+		info, NewSessionManager(0), NewAdmissionController(0, nil),
+		NewDrainer(DrainOption{}), proto.NewCodec(),
+	)
+}
+
+func testWebSocketServer(t *testing.T, l *WebSocketListener, url string) {
+	ch := newInfoConnectionHandler()
+	srv := NewWebSocketServer(l, ch)
+	go srv.Serve()
+	defer srv.Close()
+
+	c := client.NewWebSocketClient(url)
+	require.NoError(t, c.Connect())
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		resp, err := c.Call(ctx, &proto.InfoRequest{})
+		cancel()
+
+		require.NoError(t, err)
+		assert.Equal(t, "test", resp.GetResponse().GetInfo().GetServerName())
+	}
+
+	assert.Equal(t, 1, ch.SessManager.Count())
+	for _, s := range ch.SessManager.ListAll() {
+		assert.Equal(t, NetworkWebSocket, s.Conn.RemoteAddr().Network())
+	}
+}
+
+func TestWebSocketServerOwnEndpoint(t *testing.T) {
+	l, err := ListenWebSocket("127.0.0.1:0", "/ws")
+	require.NoError(t, err)
+
+	testWebSocketServer(t, l, "ws://"+l.Addr().String()+"/ws")
+}
+
+func TestWebSocketServerMounted(t *testing.T) {
+	l := NewWebSocketListener("mounted")
+	hs := httptest.NewServer(l)
+	defer hs.Close()
+
+	testWebSocketServer(t, l, strings.Replace(hs.URL, "http://", "ws://", 1))
+}
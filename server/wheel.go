@@ -0,0 +1,100 @@
+package server
+
+import "time"
+
+// timingWheel schedules session expiry in a hashed timing wheel, whose span covers
+// the session timeout, so that each session is only touched when its scheduled
+// deadline is due rather than scanning all sessions on every tick.
+//
+// Refreshing a session merely updates its last active timestamp without touching
+// the wheel. Once the scheduled deadline is due, the session is either expired
+// or lazily rescheduled by its actual deadline.
+//
+// The wheel is not safe for concurrent use, which is guarded by the lock of the
+// session shard owning it.
+type timingWheel struct {
+	tick    time.Duration           // Duration of each slot
+	timeout time.Duration           // Inactive duration before session expired
+	slots   []map[*Session]struct{} // Sessions scheduled by deadline tick
+	current int64                   // Last advanced tick
+}
+
+func newTimingWheel(tick, timeout time.Duration, now time.Time) *timingWheel {
+	// The span of the wheel must cover the timeout plus the rounding ticks,
+	// so that the scheduled deadline never wraps around the wheel.
+	n := int(timeout/tick) + 2
+
+	w := &timingWheel{
+		tick:    tick,
+		timeout: timeout,
+		slots:   make([]map[*Session]struct{}, n),
+		current: now.UnixNano() / int64(tick),
+	}
+	for i := range w.slots {
+		w.slots[i] = make(map[*Session]struct{})
+	}
+
+	return w
+}
+
+// add schedules the session by its deadline.
+func (w *timingWheel) add(s *Session) {
+	w.schedule(s)
+}
+
+// remove unschedules the session.
+func (w *timingWheel) remove(s *Session) {
+	if s.wheelSlot >= 0 {
+		delete(w.slots[s.wheelSlot], s)
+		s.wheelSlot = -1
+	}
+}
+
+// advance moves the wheel forward to the current time, and returns the
+// expired sessions which are also unscheduled.
+func (w *timingWheel) advance(now time.Time) (expired []*Session) {
+	nowNano := now.UnixNano()
+	nowTick := nowNano / int64(w.tick)
+
+	// No need to go around the wheel more than once if fell behind.
+	from := w.current + 1
+	if n := int64(len(w.slots)); nowTick-w.current > n {
+		from = nowTick - n + 1
+	}
+
+	for t := from; t <= nowTick; t++ {
+		idx := int(t % int64(len(w.slots)))
+		slot := w.slots[idx]
+
+		for s := range slot {
+			delete(slot, s)
+			s.wheelSlot = -1
+
+			if s.deadline(w.timeout) <= nowNano {
+				expired = append(expired, s)
+				continue
+			}
+
+			// Refreshed in the meantime, reschedule by the actual deadline.
+			w.schedule(s)
+		}
+	}
+
+	w.current = nowTick
+	return expired
+}
+
+func (w *timingWheel) schedule(s *Session) {
+	if s.wheelSlot >= 0 {
+		delete(w.slots[s.wheelSlot], s)
+	}
+
+	// Round up to the next tick, which is always ahead of the current tick.
+	dtick := s.deadline(w.timeout)/int64(w.tick) + 1
+	if dtick <= w.current {
+		dtick = w.current + 1
+	}
+
+	s.wheelSlot = int(dtick % int64(len(w.slots)))
+	w.slots[s.wheelSlot][s] = struct{}{}
+}
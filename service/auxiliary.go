@@ -2,20 +2,30 @@ package service
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	gometrics "github.com/rcrowley/go-metrics"
 	"github.com/wanliqun/cgo-game-server/common"
 	"github.com/wanliqun/cgo-game-server/config"
 	"github.com/wanliqun/cgo-game-server/metrics"
+	"github.com/wanliqun/cgo-game-server/proto"
 	"github.com/wanliqun/cgo-game-server/server"
 )
 
 type ServerStatus struct {
-	ServerName       string
-	Uptime           time.Duration
-	NumOnlinePlayers int32
-	TotalConnections int32
+	ServerName                     string
+	Uptime                         time.Duration
+	NumOnlinePlayers               int32
+	TotalConnections               int32
+	RejectedConnections            int64
+	RejectedConnectionsByTransport map[string]int64
+	LoginQueueLength               int
+	LoginQueueAvgWaitTime          time.Duration
+	LoginQueueMaxWaitTime          time.Duration
+	Draining                       bool
+	DrainElapsed                   time.Duration
+	DrainInFlightRequests          int64
 }
 
 type AuxiliaryService struct {
@@ -23,30 +33,99 @@ type AuxiliaryService struct {
 	Config    *config.Config
 	playerSvc *PlayerService
 	sessMgr   *server.SessionManager
+	admission *server.AdmissionController
+	drainer   *server.Drainer
 	start     time.Time
 }
 
 func NewAuxiliaryService(
-	cfg *config.Config, g common.MonickerGenerator,
-	svc *PlayerService, mgr *server.SessionManager) *AuxiliaryService {
+	cfg *config.Config, g common.MonickerGenerator, svc *PlayerService,
+	mgr *server.SessionManager, ac *server.AdmissionController,
+	drainer *server.Drainer) *AuxiliaryService {
 	return &AuxiliaryService{
 		MonickerGenerator: g,
 		Config:            cfg,
 		playerSvc:         svc,
 		sessMgr:           mgr,
+		admission:         ac,
+		drainer:           drainer,
 		start:             time.Now(),
 	}
 }
 
+// SessionStats is the statistics of the outbound queue of a session.
+type SessionStats struct {
+	ID                 string
+	RemoteAddr         string
+	Username           string // Empty if not logged in
+	LastActive         time.Time
+	OutboundQueueDepth int
+	OutboundQueueSize  int
+	OutboundDrops      int64
+}
+
+// CollectSessionStats collects the statistics of at most limit sessions, which
+// are sorted by the outbound drops and then queue depth, so that slow consumers
+// come first.
+func (s *AuxiliaryService) CollectSessionStats(limit int) []*SessionStats {
+	sessions := s.sessMgr.ListAll()
+
+	stats := make([]*SessionStats, 0, len(sessions))
+	for _, sess := range sessions {
+		st := &SessionStats{
+			ID:                 sess.ID,
+			LastActive:         sess.LastActive(),
+			OutboundQueueDepth: sess.OutboundDepth(),
+			OutboundQueueSize:  sess.OutboundCapacity(),
+			OutboundDrops:      sess.OutboundDrops(),
+		}
+		if addr := sess.RemoteAddr(); addr != nil {
+			st.RemoteAddr = addr.String()
+		}
+		if player := s.playerSvc.GetBySession(sess.ID); player != nil {
+			st.Username = player.Username
+		}
+		stats = append(stats, st)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].OutboundDrops != stats[j].OutboundDrops {
+			return stats[i].OutboundDrops > stats[j].OutboundDrops
+		}
+		return stats[i].OutboundQueueDepth > stats[j].OutboundQueueDepth
+	})
+
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+
+	return stats
+}
+
 func (s *AuxiliaryService) CollectServerStatus() *ServerStatus {
+	queueStats := s.playerSvc.LoginQueueStats()
+	drainStatus := s.drainer.Status()
 	return &ServerStatus{
-		ServerName:       s.Config.Server.Name,
-		Uptime:           time.Since(s.start),
-		NumOnlinePlayers: int32(s.playerSvc.Count()),
-		TotalConnections: int32(s.sessMgr.Count()),
+		ServerName:                     s.Config.Server.Name,
+		Uptime:                         time.Since(s.start),
+		NumOnlinePlayers:               int32(s.playerSvc.Count()),
+		TotalConnections:               int32(s.sessMgr.Count()),
+		RejectedConnections:            s.admission.Rejected(),
+		RejectedConnectionsByTransport: s.admission.RejectedByTransport(),
+		LoginQueueLength:               queueStats.Length,
+		LoginQueueAvgWaitTime:          queueStats.AvgWaitTime,
+		LoginQueueMaxWaitTime:          queueStats.MaxWaitTime,
+		Draining:                       drainStatus.Draining,
+		DrainElapsed:                   drainStatus.Elapsed,
+		DrainInFlightRequests:          drainStatus.InFlightRequests,
 	}
 }
 
+// LoginQueueStats returns the statistics of the login queue.
+func (s *AuxiliaryService) LoginQueueStats() *LoginQueueStats {
+	return s.playerSvc.LoginQueueStats()
+}
+
 func (s *AuxiliaryService) GatherOverallRPCRateMetrics() map[string]string {
 	t := metrics.RPC.OverallRpcRateTimer()
 	return map[string]string{
@@ -61,6 +140,67 @@ func (s *AuxiliaryService) GatherOverallRPCRateMetrics() map[string]string {
 	}
 }
 
+// GatherCompressionMetrics gathers the compression ratio and CPU time of each
+// compression algorithm.
+func (s *AuxiliaryService) GatherCompressionMetrics() map[string]string {
+	compressionMetrics := make(map[string]string)
+	for _, c := range []proto.Compression{proto.Compression_SNAPPY, proto.Compression_ZSTD} {
+		stats := proto.GetCompressionStats(c)
+
+		compressionMetrics[fmt.Sprintf("%s Compression Ratio", c)] = fmt.Sprintf("%.2f", stats.Ratio)
+		compressionMetrics[fmt.Sprintf("%s Compressed Frames", c)] = fmt.Sprintf("%d", stats.NumCompress)
+		compressionMetrics[fmt.Sprintf("%s Mean Compress Time", c)] = fmt.Sprintf("%.1f(us)", float64(stats.CompressTime)/1e3)
+		compressionMetrics[fmt.Sprintf("%s Decompressed Frames", c)] = fmt.Sprintf("%d", stats.NumDecompress)
+		compressionMetrics[fmt.Sprintf("%s Mean Decompress Time", c)] = fmt.Sprintf("%.1f(us)", float64(stats.DecompressTime)/1e3)
+	}
+
+	return compressionMetrics
+}
+
+// GatherFrameViolationMetrics gathers the number of sessions disconnected by
+// each frame violation.
+func (s *AuxiliaryService) GatherFrameViolationMetrics() map[string]string {
+	violationMetrics := make(map[string]string)
+	for reason, count := range server.GetFrameViolationCounts() {
+		violationMetrics[fmt.Sprintf("Frame Violation %s", reason)] = fmt.Sprintf("%d", count)
+	}
+
+	return violationMetrics
+}
+
+// GatherIPLimitMetrics gathers the number of connections rejected by each per-IP
+// limit violation.
+func (s *AuxiliaryService) GatherIPLimitMetrics() map[string]string {
+	ipLimitMetrics := make(map[string]string)
+	for violation, count := range server.GetIPLimitRejections() {
+		ipLimitMetrics[fmt.Sprintf("IP Limit Rejected %s", violation)] = fmt.Sprintf("%d", count)
+	}
+
+	return ipLimitMetrics
+}
+
+// GatherSlowConsumerMetrics gathers the number of outbound messages dropped or
+// sessions disconnected by each slow-consumer policy.
+func (s *AuxiliaryService) GatherSlowConsumerMetrics() map[string]string {
+	slowConsumerMetrics := make(map[string]string)
+	for policy, count := range server.GetSlowConsumerCounts() {
+		slowConsumerMetrics[fmt.Sprintf("Slow Consumer %s", policy)] = fmt.Sprintf("%d", count)
+	}
+
+	return slowConsumerMetrics
+}
+
+// GatherDatagramMetrics gathers the number of datagrams received, sent and dropped
+// for each reason over the datagram channels.
+func (s *AuxiliaryService) GatherDatagramMetrics() map[string]string {
+	datagramMetrics := make(map[string]string)
+	for name, count := range server.GetDatagramCounts() {
+		datagramMetrics[fmt.Sprintf("Datagram %s", name)] = fmt.Sprintf("%d", count)
+	}
+
+	return datagramMetrics
+}
+
 func (s *AuxiliaryService) GatherAllRPCRateMetrics() map[string]string {
 	rpcRateMetrics := make(map[string]string)
 	metrics.RPC.IterateRateTimers(func(key string, t gometrics.Timer) {
@@ -8,6 +8,7 @@ import (
 
 const (
 	StatusInvalidPassword = iota + 1000
+	StatusLoginQueueFull
 )
 
 var (
@@ -15,4 +16,8 @@ var (
 		Code: StatusInvalidPassword,
 		Err:  errors.New("invalid password"),
 	}
+	errLoginQueueFull = &server.StatusError{
+		Code: StatusLoginQueueFull,
+		Err:  errors.New("login queue full"),
+	}
 )
@@ -14,9 +14,13 @@ type Factory struct {
 func NewFactory(
 	conf *config.Config,
 	sessionMgr *server.SessionManager,
+	admission *server.AdmissionController,
+	drainer *server.Drainer,
 	monickerGenerator common.MonickerGenerator) *Factory {
 
-	playerSvc := NewPlayerService(conf, sessionMgr)
-	auxSvc := NewAuxiliaryService(conf, monickerGenerator, playerSvc, sessionMgr)
+	playerSvc := NewPlayerService(conf, sessionMgr, drainer)
+	auxSvc := NewAuxiliaryService(
+		conf, monickerGenerator, playerSvc, sessionMgr, admission, drainer,
+	)
 	return &Factory{Player: playerSvc, Auxiliary: auxSvc}
 }
@@ -3,15 +3,21 @@ package service
 import (
 	"context"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/badu/bus"
+	"github.com/sirupsen/logrus"
 	"github.com/wanliqun/cgo-game-server/config"
 	"github.com/wanliqun/cgo-game-server/proto"
 	"github.com/wanliqun/cgo-game-server/server"
+	"github.com/wanliqun/cgo-game-server/util"
 )
 
 const (
 	CtxKeyPlayer server.ContextKey = "player"
+
+	kickReasonDuplicateLogin = "logged in from another session"
 )
 
 func NewContextFromPlayer(parent context.Context, player *Player) context.Context {
@@ -28,95 +34,272 @@ type Player struct {
 	Session  *server.Session
 }
 
+// PlayerService manages online players in lock-striped registries, which are
+// sharded by username and session ID respectively.
 type PlayerService struct {
-	mu          sync.Mutex
 	config      *config.Config
-	usrPlayers  map[string]*Player // username=>Player
-	sessPlayers map[string]*Player // session=>Player
+	usrPlayers  *util.ShardedMap[*Player] // username=>Player
+	sessPlayers *util.ShardedMap[*Player] // session=>Player
+	slots       atomic.Int64              // Number of occupied player slots
+	admitMu     sync.Mutex                // Serializes admission once server is full
 	sessionMgr  *server.SessionManager
+	drainer     *server.Drainer
+	loginQueue  *LoginQueue
 }
 
-func NewPlayerService(conf *config.Config, sessionMgr *server.SessionManager) *PlayerService {
+func NewPlayerService(
+	conf *config.Config, sessionMgr *server.SessionManager, drainer *server.Drainer) *PlayerService {
 	ps := &PlayerService{
 		config:      conf,
 		sessionMgr:  sessionMgr,
-		usrPlayers:  make(map[string]*Player),
-		sessPlayers: make(map[string]*Player),
+		drainer:     drainer,
+		usrPlayers:  util.NewShardedMap[*Player](util.DefaultNumShards),
+		sessPlayers: util.NewShardedMap[*Player](util.DefaultNumShards),
+		loginQueue:  NewLoginQueue(),
 	}
 	bus.Sub(ps.OnSessionTerminatedEvent)
 
 	return ps
 }
 
+// Add adds the player regardless of the max player capacity, which replaces
+// the player with the same username if any.
 func (s *PlayerService) Add(p *Player) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.slots.Add(1)
+	if replaced := s.add(p); replaced != nil {
+		// Kicked asynchronously, since closing the old session may block on flushing.
+		go s.kick(replaced, kickReasonDuplicateLogin)
+	}
+}
+
+// add adds the player with an occupied slot. If the player with the same
+// username exists, it is replaced and returned, whose slot is released.
+func (s *PlayerService) add(p *Player) (replaced *Player) {
+	old, loaded := s.usrPlayers.Swap(p.Username, p)
+	if loaded && s.sessPlayers.CompareAndDelete(old.Session.ID, old) {
+		s.slots.Add(-1)
+		replaced = old
+	}
 
-	s.usrPlayers[p.Username] = p
-	s.sessPlayers[p.Session.ID] = p
+	s.sessPlayers.Set(p.Session.ID, p)
+	return replaced
 }
 
+// remove removes the player and releases its slot.
+func (s *PlayerService) remove(p *Player) bool {
+	if !s.sessPlayers.CompareAndDelete(p.Session.ID, p) {
+		return false
+	}
+
+	s.usrPlayers.CompareAndDelete(p.Username, p)
+	s.slots.Add(-1)
+	return true
+}
+
+// tryReserve tries to occupy a player slot within the max player capacity.
+func (s *PlayerService) tryReserve() bool {
+	for {
+		n := s.slots.Load()
+		if n >= int64(s.config.Server.MaxPlayerCapacity) {
+			return false
+		}
+
+		if s.slots.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// Kickoff kicks off the player and admits the players waiting in the login
+// queue if any slot is freed.
 func (s *PlayerService) Kickoff(p *Player) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	removed := s.remove(p)
+	s.sessionMgr.Terminate(p.Session)
 
-	delete(s.usrPlayers, p.Username)
-	delete(s.sessPlayers, p.Session.ID)
+	if removed {
+		s.admitQueued()
+	}
+}
 
+// kick notifies the player before kicked off.
+func (s *PlayerService) kick(p *Player, reason string) {
+	s.notifyKicked(p.Session, reason)
 	s.sessionMgr.Terminate(p.Session)
 }
 
 func (s *PlayerService) GetByUser(username string) *Player {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	return s.usrPlayers[username]
+	p, _ := s.usrPlayers.Get(username)
+	return p
 }
 
 func (s *PlayerService) GetBySession(sessionID string) *Player {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	return s.sessPlayers[sessionID]
+	p, _ := s.sessPlayers.Get(sessionID)
+	return p
 }
 
+// Count returns the number of online players in constant time.
 func (s *PlayerService) Count() int {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.usrPlayers.Len()
+}
 
-	return len(s.usrPlayers)
+// LoginQueueStats returns the statistics of the login queue.
+func (s *PlayerService) LoginQueueStats() *LoginQueueStats {
+	return s.loginQueue.Stats()
 }
 
-func (s *PlayerService) Login(req *proto.LoginRequest, session *server.Session) (*Player, error) {
+// Login logins the player with the session. If the server is full, the login
+// request waits in the login queue, and the returned queue status tells its
+// position in line.
+func (s *PlayerService) Login(
+	req *proto.LoginRequest, session *server.Session) (*Player, *proto.LoginQueueStatus, error) {
+	if s.drainer.Draining() {
+		return nil, nil, server.ErrServerDraining
+	}
+
 	if req.Password != s.config.Server.Password {
-		return nil, errInvalidPassword
+		return nil, nil, errInvalidPassword
 	}
 
-	player := s.GetByUser(req.Username)
+	if player := s.GetBySession(session.ID); player != nil {
+		if player.Username == req.Username {
+			// User already logined with the same session.
+			return player, nil, nil
+		}
 
-	if player != nil && player.Session.ID == session.ID {
-		// User already logined with the same session.
-		return player, nil
+		// Log off the former user of the same session.
+		if s.remove(player) {
+			defer s.admitQueued()
+		}
 	}
 
-	if player != nil {
-		// Kick off the player with an old session.
-		s.Kickoff(player)
+	player := &Player{
+		Username: req.Username,
+		Session:  session,
 	}
 
-	// TODO: Enforce max player capacity in case of server overload.
+	if s.GetByUser(req.Username) != nil {
+		// Replace the player with an old session, which takes over the occupied slot.
+		// The slot might be over capacity in case the old player left in the meantime.
+		s.Add(player)
+		return player, nil, nil
+	}
 
-	player = &Player{
-		Username: req.Username,
-		Session:  session,
+	// Enforce max player capacity in case of server overload.
+	if s.loginQueue.Len() == 0 && s.tryReserve() {
+		s.admit(player)
+		return player, nil, nil
+	}
+
+	s.admitMu.Lock()
+	defer s.admitMu.Unlock()
+
+	// Double check while holding the admission lock, so that the slot freed in
+	// the meantime is either taken or to admit the enqueued ticket afterwards.
+	if s.loginQueue.Len() == 0 && s.tryReserve() {
+		s.admit(player)
+		return player, nil, nil
 	}
-	s.Add(player)
 
-	return player, nil
+	status, err := s.enqueue(req.Username, session)
+	return nil, status, err
+}
+
+// admit adds the player with the reserved slot.
+func (s *PlayerService) admit(p *Player) {
+	if replaced := s.add(p); replaced != nil {
+		go s.kick(replaced, kickReasonDuplicateLogin)
+	}
+}
+
+func (s *PlayerService) enqueue(username string, session *server.Session) (*proto.LoginQueueStatus, error) {
+	maxQueueSize := s.config.Server.MaxLoginQueueSize
+	if maxQueueSize > 0 && s.loginQueue.Len() >= maxQueueSize {
+		return nil, errLoginQueueFull
+	}
+
+	pos, replaced := s.loginQueue.Enqueue(&LoginTicket{
+		Username:   username,
+		Session:    session,
+		EnqueuedAt: time.Now(),
+	})
+	if replaced != nil && replaced.Session.ID != session.ID {
+		// Terminate the old session waiting in line with the same username.
+		go s.kick(&Player{Username: replaced.Username, Session: replaced.Session}, kickReasonDuplicateLogin)
+	}
+
+	return newLoginQueueStatus(pos, s.loginQueue.ETA(pos)), nil
+}
+
+// admitQueued admits the players waiting in the login queue as long as
+// there are free slots.
+func (s *PlayerService) admitQueued() {
+	var admitted []*Player
+
+	s.admitMu.Lock()
+	for s.loginQueue.Len() > 0 && s.tryReserve() {
+		t := s.loginQueue.Dequeue()
+		if t == nil {
+			s.slots.Add(-1)
+			break
+		}
+
+		p := &Player{Username: t.Username, Session: t.Session}
+		s.admit(p)
+		admitted = append(admitted, p)
+	}
+	s.admitMu.Unlock()
+
+	if len(admitted) == 0 {
+		return
+	}
+
+	go s.notifyQueue(admitted)
+}
+
+// notifyQueue notifies the admitted players and pushes the updated position
+// and ETA to players still waiting in line.
+func (s *PlayerService) notifyQueue(admitted []*Player) {
+	for _, p := range admitted {
+		msg, _ := proto.NewEventMessage(&proto.LoginResponse{})
+		if err := p.Session.Push(msg); err != nil {
+			logrus.WithField("username", p.Username).
+				WithError(err).
+				Debug("Failed to notify player admitted from login queue")
+		}
+	}
+
+	s.loginQueue.Iterate(func(t *LoginTicket, pos int, eta time.Duration) {
+		msg, _ := proto.NewEventMessage(newLoginQueueStatus(pos, eta))
+		if err := t.Session.Push(msg); err != nil {
+			logrus.WithField("username", t.Username).
+				WithError(err).
+				Debug("Failed to push login queue status")
+		}
+	})
+}
+
+func (s *PlayerService) notifyKicked(session *server.Session, reason string) {
+	msg, _ := proto.NewEventMessage(&proto.KickedEvent{Reason: reason})
+	if err := session.Push(msg); err != nil {
+		logrus.WithField("sessionID", session.ID).
+			WithError(err).
+			Debug("Failed to notify player kicked off")
+	}
 }
 
 func (s *PlayerService) OnSessionTerminatedEvent(e *server.SessionTerminatedEvent) {
 	if player := s.GetBySession(e.Sess.ID); player != nil {
 		s.Kickoff(player)
+		return
+	}
+
+	// Leave the login queue if still waiting in line.
+	s.loginQueue.Remove(e.Sess.ID)
+}
+
+func newLoginQueueStatus(pos int, eta time.Duration) *proto.LoginQueueStatus {
+	return &proto.LoginQueueStatus{
+		Position:   int32(pos),
+		EtaSeconds: int64(eta.Round(time.Second) / time.Second),
 	}
 }
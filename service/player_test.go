@@ -0,0 +1,132 @@
+package service
+
+import (
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wanliqun/cgo-game-server/config"
+	"github.com/wanliqun/cgo-game-server/proto"
+	"github.com/wanliqun/cgo-game-server/server"
+)
+
+func newTestPlayerService(capacity int) *PlayerService {
+	conf := &config.Config{}
+	conf.Server.Password = "helloworld"
+	conf.Server.MaxPlayerCapacity = capacity
+
+	return NewPlayerService(conf, server.NewSessionManager(0), server.NewDrainer(server.DrainOption{}))
+}
+
+func TestPlayerServiceLoginQueue(t *testing.T) {
+	svc := newTestPlayerService(1)
+
+	s1, s2 := server.NewSession(nil, nil), server.NewSession(nil, nil)
+	svc.sessionMgr.Add(s1)
+	svc.sessionMgr.Add(s2)
+
+	p1, status, err := svc.Login(&proto.LoginRequest{Username: "p1", Password: "helloworld"}, s1)
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+	assert.NotNil(t, p1)
+
+	// Server is full, wait in the login queue.
+	p2, status, err := svc.Login(&proto.LoginRequest{Username: "p2", Password: "helloworld"}, s2)
+	assert.NoError(t, err)
+	assert.Nil(t, p2)
+	assert.Equal(t, int32(1), status.GetPosition())
+	assert.Equal(t, 1, svc.LoginQueueStats().Length)
+
+	// Admitted once the slot is freed.
+	svc.Kickoff(p1)
+	assert.Equal(t, 1, svc.Count())
+	assert.Equal(t, "p2", svc.GetBySession(s2.ID).Username)
+	assert.Equal(t, 0, svc.LoginQueueStats().Length)
+}
+
+func TestPlayerServiceDuplicateLogin(t *testing.T) {
+	svc := newTestPlayerService(1)
+
+	// Old session blocks on flushing the kicked notice, since never read.
+	conn, peer := net.Pipe()
+	defer peer.Close()
+
+	s1, s2 := server.NewSession(conn, proto.NewCodec()), server.NewSession(nil, nil)
+	s1.StartWriter()
+	svc.sessionMgr.Add(s1)
+	svc.sessionMgr.Add(s2)
+
+	req := &proto.LoginRequest{Username: "p1", Password: "helloworld"}
+	_, _, err := svc.Login(req, s1)
+	assert.NoError(t, err)
+
+	// Login from another session isn't blocked by kicking off the old one.
+	start := time.Now()
+	p, status, err := svc.Login(req, s2)
+	assert.NoError(t, err)
+	assert.Nil(t, status)
+	assert.Less(t, time.Since(start), time.Second)
+
+	assert.Equal(t, s2, p.Session)
+	assert.Equal(t, p, svc.GetByUser("p1"))
+	assert.Eventually(t, func() bool {
+		return svc.sessionMgr.Count() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPlayerServiceRepeatedQueuedLogin(t *testing.T) {
+	svc := newTestPlayerService(1)
+
+	sessions := []*server.Session{
+		server.NewSession(nil, nil), server.NewSession(nil, nil), server.NewSession(nil, nil),
+	}
+	for i, s := range sessions {
+		_, _, err := svc.Login(&proto.LoginRequest{Username: "p" + strconv.Itoa(i), Password: "helloworld"}, s)
+		assert.NoError(t, err)
+	}
+
+	// Repeated login keeps the position in line.
+	_, status, err := svc.Login(&proto.LoginRequest{Username: "p1", Password: "helloworld"}, sessions[1])
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), status.GetPosition())
+	assert.Equal(t, 2, svc.LoginQueueStats().Length)
+}
+
+func BenchmarkPlayerServiceLoginStorm(b *testing.B) {
+	svc := newTestPlayerService(b.N)
+
+	var idx atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			req := &proto.LoginRequest{
+				Username: "player" + strconv.Itoa(int(idx.Add(1))),
+				Password: "helloworld",
+			}
+			svc.Login(req, server.NewSession(nil, nil))
+		}
+	})
+}
+
+func BenchmarkPlayerServiceGetBySession(b *testing.B) {
+	const numPlayers = 100_000
+
+	svc := newTestPlayerService(numPlayers)
+	sessionIDs := make([]string, numPlayers)
+	for i := range sessionIDs {
+		sess := server.NewSession(nil, nil)
+		svc.Add(&Player{Username: "player" + strconv.Itoa(i), Session: sess})
+		sessionIDs[i] = sess.ID
+	}
+
+	var idx atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			svc.GetBySession(sessionIDs[int(idx.Add(1))%numPlayers])
+		}
+	})
+}
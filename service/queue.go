@@ -0,0 +1,201 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/wanliqun/cgo-game-server/server"
+)
+
+const (
+	// Smoothing factor for the exponential moving average of admission interval.
+	admissionIntervalAlpha = 0.2
+)
+
+// LoginTicket represents a login request waiting in the login queue.
+type LoginTicket struct {
+	Username   string
+	Session    *server.Session
+	EnqueuedAt time.Time
+}
+
+// LoginQueueStats is a snapshot of the login queue statistics.
+type LoginQueueStats struct {
+	Length      int           // Number of tickets waiting in line
+	AvgWaitTime time.Duration // Average wait time of admitted tickets
+	MaxWaitTime time.Duration // Max wait time of admitted tickets
+	NumAdmitted int64         // Total number of admitted tickets
+}
+
+// LoginQueue is a FIFO queue of login tickets, which also estimates the wait time
+// from the observed admission rate.
+type LoginQueue struct {
+	mu       sync.Mutex
+	tickets  *list.List               // FIFO queue of *LoginTicket
+	elements map[string]*list.Element // session ID => queue element
+	users    map[string]*list.Element // username => queue element
+
+	lastAdmitAt      time.Time     // Last admission time
+	avgAdmitInterval time.Duration // Moving average of the admission interval
+	totalWaitTime    time.Duration // Total wait time of admitted tickets
+	maxWaitTime      time.Duration // Max wait time of admitted tickets
+	numAdmitted      int64         // Total number of admitted tickets
+}
+
+func NewLoginQueue() *LoginQueue {
+	return &LoginQueue{
+		tickets:  list.New(),
+		elements: make(map[string]*list.Element),
+		users:    make(map[string]*list.Element),
+	}
+}
+
+// Enqueue appends the ticket to the end of the queue, and returns its position.
+// If the username is already waiting in line, the ticket takes over the old
+// position instead and the replaced ticket is also returned, unless it's the
+// repeated login of the same session, which keeps the existing ticket in place.
+func (q *LoginQueue) Enqueue(t *LoginTicket) (pos int, replaced *LoginTicket) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if e, ok := q.elements[t.Session.ID]; ok {
+		if e.Value.(*LoginTicket).Username == t.Username {
+			// Repeated login of the same session and username.
+			return q.position(e), nil
+		}
+
+		// Session already in line with another username, drop the old ticket.
+		q.remove(e)
+	}
+
+	if e, ok := q.users[t.Username]; ok {
+		// Username already in line, take over the old position.
+		v := e.Value.(*LoginTicket)
+		delete(q.elements, v.Session.ID)
+
+		t.EnqueuedAt = v.EnqueuedAt
+		e.Value = t
+		q.elements[t.Session.ID] = e
+		return q.position(e), v
+	}
+
+	e := q.tickets.PushBack(t)
+	q.elements[t.Session.ID] = e
+	q.users[t.Username] = e
+
+	return q.tickets.Len(), nil
+}
+
+// Remove removes the ticket of the session from the queue.
+func (q *LoginQueue) Remove(sessionID string) *LoginTicket {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.elements[sessionID]
+	if !ok {
+		return nil
+	}
+
+	return q.remove(e)
+}
+
+// Dequeue pops the ticket at the front of the queue for admission.
+func (q *LoginQueue) Dequeue() *LoginTicket {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e := q.tickets.Front()
+	if e == nil {
+		return nil
+	}
+
+	t := q.remove(e)
+
+	// Update admission statistics
+	now := time.Now()
+	if !q.lastAdmitAt.IsZero() {
+		interval := now.Sub(q.lastAdmitAt)
+		if q.avgAdmitInterval == 0 {
+			q.avgAdmitInterval = interval
+		} else {
+			q.avgAdmitInterval = time.Duration(admissionIntervalAlpha*float64(interval) +
+				(1-admissionIntervalAlpha)*float64(q.avgAdmitInterval))
+		}
+	}
+	q.lastAdmitAt = now
+
+	wait := now.Sub(t.EnqueuedAt)
+	q.totalWaitTime += wait
+	if wait > q.maxWaitTime {
+		q.maxWaitTime = wait
+	}
+	q.numAdmitted++
+
+	return t
+}
+
+// Len returns the number of tickets waiting in line.
+func (q *LoginQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.tickets.Len()
+}
+
+// ETA estimates the wait time for the specified position.
+func (q *LoginQueue) ETA(pos int) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.avgAdmitInterval * time.Duration(pos)
+}
+
+// Iterate iterates all the waiting tickets along with their positions and ETAs.
+func (q *LoginQueue) Iterate(cb func(t *LoginTicket, pos int, eta time.Duration)) {
+	q.mu.Lock()
+	tickets := make([]*LoginTicket, 0, q.tickets.Len())
+	for e := q.tickets.Front(); e != nil; e = e.Next() {
+		tickets = append(tickets, e.Value.(*LoginTicket))
+	}
+	interval := q.avgAdmitInterval
+	q.mu.Unlock()
+
+	for i, t := range tickets {
+		cb(t, i+1, interval*time.Duration(i+1))
+	}
+}
+
+// Stats returns the statistics of the login queue.
+func (q *LoginQueue) Stats() *LoginQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := &LoginQueueStats{
+		Length:      q.tickets.Len(),
+		MaxWaitTime: q.maxWaitTime,
+		NumAdmitted: q.numAdmitted,
+	}
+	if q.numAdmitted > 0 {
+		stats.AvgWaitTime = q.totalWaitTime / time.Duration(q.numAdmitted)
+	}
+
+	return stats
+}
+
+func (q *LoginQueue) remove(e *list.Element) *LoginTicket {
+	t := q.tickets.Remove(e).(*LoginTicket)
+	delete(q.elements, t.Session.ID)
+	delete(q.users, t.Username)
+
+	return t
+}
+
+func (q *LoginQueue) position(e *list.Element) int {
+	pos := 1
+	for v := q.tickets.Front(); v != nil && v != e; v = v.Next() {
+		pos++
+	}
+
+	return pos
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wanliqun/cgo-game-server/server"
+)
+
+func newTestTicket(username string, session *server.Session) *LoginTicket {
+	return &LoginTicket{Username: username, Session: session, EnqueuedAt: time.Now()}
+}
+
+func TestLoginQueue(t *testing.T) {
+	q := NewLoginQueue()
+	s1, s2, s3 := server.NewSession(nil, nil), server.NewSession(nil, nil), server.NewSession(nil, nil)
+
+	for i, ticket := range []*LoginTicket{
+		newTestTicket("p1", s1), newTestTicket("p2", s2), newTestTicket("p3", s3),
+	} {
+		pos, replaced := q.Enqueue(ticket)
+		assert.Equal(t, i+1, pos)
+		assert.Nil(t, replaced)
+	}
+
+	// Repeated login of the same session keeps the existing ticket in place.
+	pos, replaced := q.Enqueue(newTestTicket("p2", s2))
+	assert.Equal(t, 2, pos)
+	assert.Nil(t, replaced)
+	assert.Equal(t, 3, q.Len())
+
+	// Same username from another session takes over the position.
+	s4 := server.NewSession(nil, nil)
+	pos, replaced = q.Enqueue(newTestTicket("p2", s4))
+	assert.Equal(t, 2, pos)
+	assert.Equal(t, s2, replaced.Session)
+
+	// Another username of the same session is queued again at the end.
+	pos, replaced = q.Enqueue(newTestTicket("p5", s1))
+	assert.Equal(t, 3, pos)
+	assert.Nil(t, replaced)
+
+	assert.Equal(t, "p3", q.Remove(s3.ID).Username)
+	assert.Nil(t, q.Remove(s3.ID))
+
+	// Dequeued in FIFO order.
+	assert.Equal(t, s4, q.Dequeue().Session)
+	assert.Equal(t, s1, q.Dequeue().Session)
+	assert.Nil(t, q.Dequeue())
+
+	stats := q.Stats()
+	assert.Zero(t, stats.Length)
+	assert.EqualValues(t, 2, stats.NumAdmitted)
+}
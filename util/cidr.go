@@ -0,0 +1,57 @@
+package util
+
+import (
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CIDRSet is a list of IP networks, eg., trusted sources.
+type CIDRSet []*net.IPNet
+
+// ParseCIDRs parses the CIDR notations, eg., "10.0.0.0/8". A bare IP address
+// is parsed as the network of the single address.
+func ParseCIDRs(cidrs []string) (CIDRSet, error) {
+	var set CIDRSet
+	for _, v := range cidrs {
+		if !strings.Contains(v, "/") {
+			if ip := net.ParseIP(v); ip != nil && ip.To4() != nil {
+				v += "/32"
+			} else {
+				v += "/128"
+			}
+		}
+
+		_, ipnet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid CIDR %v", v)
+		}
+		set = append(set, ipnet)
+	}
+
+	return set, nil
+}
+
+// Contains checks if the IP belongs to any of the networks.
+func (s CIDRSet) Contains(ip net.IP) bool {
+	for _, ipnet := range s {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsAddr checks if the IP of the TCP or UDP address belongs to any of
+// the networks.
+func (s CIDRSet) ContainsAddr(addr net.Addr) bool {
+	switch v := addr.(type) {
+	case *net.TCPAddr:
+		return s.Contains(v.IP)
+	case *net.UDPAddr:
+		return s.Contains(v.IP)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCIDRs(t *testing.T) {
+	set, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	require.NoError(t, err)
+
+	assert.True(t, set.Contains(net.ParseIP("10.1.2.3")))
+	assert.True(t, set.Contains(net.ParseIP("192.168.1.1")))
+	assert.False(t, set.Contains(net.ParseIP("192.168.1.2")))
+	assert.True(t, set.ContainsAddr(&net.TCPAddr{IP: net.IPv6loopback}))
+	assert.False(t, set.ContainsAddr(&net.UDPAddr{IP: net.ParseIP("172.16.0.1")}))
+
+	_, err = ParseCIDRs([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
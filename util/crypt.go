@@ -0,0 +1,26 @@
+package util
+
+import (
+	"crypto/sha1"
+
+	"github.com/pkg/errors"
+	"github.com/xtaci/kcp-go/v5"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	kcpKeyIterations = 4096
+	kcpKeyLength     = 32 // AES-256
+)
+
+// NewKCPBlockCrypt creates the AES block crypt for KCP sessions, whose key is
+// derived from the pre-shared key and salt. Both server and client must share
+// the same key and salt.
+func NewKCPBlockCrypt(key, salt string) (kcp.BlockCrypt, error) {
+	if len(key) == 0 {
+		return nil, errors.New("empty KCP pre-shared key")
+	}
+
+	pass := pbkdf2.Key([]byte(key), []byte(salt), kcpKeyIterations, kcpKeyLength, sha1.New)
+	return kcp.NewAESBlockCrypt(pass)
+}
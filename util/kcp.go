@@ -0,0 +1,71 @@
+package util
+
+import (
+	"github.com/pkg/errors"
+	"github.com/xtaci/kcp-go/v5"
+)
+
+const (
+	KCPPresetFast           = "fast"
+	KCPPresetNormal         = "normal"
+	KCPPresetBandwidthSaver = "bandwidth-saver"
+)
+
+// KCPOption tunes the KCP sessions, see also:
+// https://github.com/skywind3000/kcp/blob/master/README.en.md#protocol-configuration
+type KCPOption struct {
+	NoDelay      bool // Whether to enable nodelay mode for faster retransmission
+	Interval     int  // Internal update interval in milliseconds
+	Resend       int  // Fast retransmit after the number of ACKs skipped, 0 to disable
+	NoCongestion bool // Whether to disable congestion control
+	SndWnd       int  // Send window size in packets
+	RcvWnd       int  // Receive window size in packets
+	MTU          int  // Max transmission unit in bytes
+	DataShards   int  // Reed-Solomon FEC data shards, 0 to disable FEC
+	ParityShards int  // Reed-Solomon FEC parity shards, 0 to disable FEC
+}
+
+// KCPPresets are the named KCP options, which trade off bandwidth for latency.
+var KCPPresets = map[string]KCPOption{
+	// Aggressive retransmission with FEC, suited to lossy mobile networks.
+	KCPPresetFast: {
+		NoDelay: true, Interval: 10, Resend: 2, NoCongestion: true,
+		SndWnd: 512, RcvWnd: 512, MTU: 1350, DataShards: 10, ParityShards: 3,
+	},
+	KCPPresetNormal: {
+		NoDelay: false, Interval: 40, Resend: 2, NoCongestion: true,
+		SndWnd: 128, RcvWnd: 128, MTU: 1400,
+	},
+	KCPPresetBandwidthSaver: {
+		NoDelay: false, Interval: 100, Resend: 0, NoCongestion: false,
+		SndWnd: 32, RcvWnd: 32, MTU: 1400,
+	},
+}
+
+// NewKCPOptionFromPreset returns the KCP option of the named preset.
+func NewKCPOptionFromPreset(preset string) (KCPOption, error) {
+	opt, ok := KCPPresets[preset]
+	if !ok {
+		return KCPOption{}, errors.Errorf("invalid KCP preset %v", preset)
+	}
+
+	return opt, nil
+}
+
+// Apply applies the option to the KCP session. FEC shards are not applicable,
+// which must be specified on listen or dial.
+func (o *KCPOption) Apply(sess *kcp.UDPSession) {
+	nodelay, nc := 0, 0
+	if o.NoDelay {
+		nodelay = 1
+	}
+	if o.NoCongestion {
+		nc = 1
+	}
+
+	sess.SetNoDelay(nodelay, o.Interval, o.Resend, nc)
+	sess.SetWindowSize(o.SndWnd, o.RcvWnd)
+	if o.MTU > 0 {
+		sess.SetMtu(o.MTU)
+	}
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xtaci/kcp-go/v5"
+)
+
+func TestNewKCPOptionFromPreset(t *testing.T) {
+	for _, preset := range []string{KCPPresetFast, KCPPresetNormal, KCPPresetBandwidthSaver} {
+		opt, err := NewKCPOptionFromPreset(preset)
+		require.NoError(t, err)
+		assert.Equal(t, KCPPresets[preset], opt)
+	}
+
+	// Fast preset trades bandwidth for latency.
+	fast, normal := KCPPresets[KCPPresetFast], KCPPresets[KCPPresetNormal]
+	assert.True(t, fast.NoDelay)
+	assert.Less(t, fast.Interval, normal.Interval)
+	assert.Greater(t, fast.ParityShards, 0)
+
+	_, err := NewKCPOptionFromPreset("turbo")
+	assert.Error(t, err)
+}
+
+func TestKCPOptionEcho(t *testing.T) {
+	// FEC shards must be consistent on both sides.
+	opt := KCPPresets[KCPPresetFast]
+
+	l, err := kcp.ListenWithOptions("127.0.0.1:0", nil, opt.DataShards, opt.ParityShards)
+	require.NoError(t, err)
+	defer l.Close()
+
+	go func() {
+		sess, err := l.AcceptKCP()
+		if err != nil {
+			return
+		}
+		defer sess.Close()
+
+		opt.Apply(sess)
+		io.Copy(sess, sess)
+	}()
+
+	sess, err := kcp.DialWithOptions(l.Addr().String(), nil, opt.DataShards, opt.ParityShards)
+	require.NoError(t, err)
+	defer sess.Close()
+
+	opt.Apply(sess)
+	sess.SetDeadline(time.Now().Add(3 * time.Second))
+
+	_, err = sess.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(sess, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(buf))
+}
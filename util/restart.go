@@ -0,0 +1,270 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Environment variable to pass the inherited files to the new process, eg.,
+	// "tcp:0.0.0.0:8765=3,udp:0.0.0.0:8765=4,ready=5,release=6".
+	envInheritedFiles = "CGO_GAME_SERVER_INHERITED_FILES"
+
+	inheritedReady   = "ready"   // Pipe to notify the parent process once ready
+	inheritedRelease = "release" // Pipe closed once the parent process released the sockets
+
+	inheritedFdOffset = 3 // Extra files start after stdin, stdout and stderr
+)
+
+var (
+	inheritOnce sync.Once
+	inheritMu   sync.Mutex
+	inherited   map[string]*os.File // Name => file inherited from the parent process
+
+	socketsMu sync.Mutex
+	sockets   = make(map[string]fileSocket) // Name => listening socket to hand over
+
+	releaseOnce sync.Once
+	released    chan struct{}
+)
+
+// fileSocket is the socket whose file descriptor can be duplicated, eg.,
+// `net.TCPListener` and `net.UDPConn`.
+type fileSocket interface {
+	File() (*os.File, error)
+}
+
+// loadInheritedFiles parses the files inherited from the parent process, and
+// hides them from the processes started later.
+func loadInheritedFiles() {
+	inherited = make(map[string]*os.File)
+
+	for _, kv := range strings.Split(os.Getenv(envInheritedFiles), ",") {
+		name, v, ok := strings.Cut(kv, "=")
+		fd, err := strconv.Atoi(v)
+		if !ok || err != nil {
+			continue
+		}
+		inherited[name] = os.NewFile(uintptr(fd), name)
+	}
+
+	os.Unsetenv(envInheritedFiles)
+}
+
+// inheritedFile takes the file inherited from the parent process by name, or
+// returns nil if not inherited.
+func inheritedFile(name string) *os.File {
+	inheritOnce.Do(loadInheritedFiles)
+
+	inheritMu.Lock()
+	defer inheritMu.Unlock()
+
+	f := inherited[name]
+	delete(inherited, name)
+	return f
+}
+
+func registerSocket(name string, s fileSocket) {
+	socketsMu.Lock()
+	defer socketsMu.Unlock()
+
+	sockets[name] = s
+}
+
+// ListenTCP listens on the TCP endpoint, or takes over the listener inherited
+// from the parent process on hot restart.
+func ListenTCP(addr string) (net.Listener, error) {
+	name := "tcp:" + addr
+
+	var l net.Listener
+	if f := inheritedFile(name); f != nil {
+		defer f.Close()
+
+		fl, err := net.FileListener(f)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed to inherit listener %v", name)
+		}
+		l = fl
+	} else {
+		nl, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		l = nl
+	}
+
+	if s, ok := l.(fileSocket); ok {
+		registerSocket(name, s)
+	}
+
+	return l, nil
+}
+
+// ListenUDP listens on the UDP endpoint, or takes over the socket inherited from
+// the parent process on hot restart, which shouldn't be read until `Released`
+// since the parent process may still be reading it.
+func ListenUDP(addr string) (conn *net.UDPConn, inherited bool, err error) {
+	name := "udp:" + addr
+
+	if f := inheritedFile(name); f != nil {
+		defer f.Close()
+
+		pc, err := net.FilePacketConn(f)
+		if err != nil {
+			return nil, false, errors.WithMessagef(err, "failed to inherit socket %v", name)
+		}
+
+		conn, ok := pc.(*net.UDPConn)
+		if !ok {
+			pc.Close()
+			return nil, false, errors.Errorf("inherited socket %v is not UDP", name)
+		}
+
+		registerSocket(name, conn)
+		return conn, true, nil
+	}
+
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if conn, err = net.ListenUDP("udp", udpAddr); err != nil {
+		return nil, false, err
+	}
+
+	registerSocket(name, conn)
+	return conn, false, nil
+}
+
+// NotifyReady notifies the parent process on hot restart, if any, that this
+// process is ready to serve.
+func NotifyReady() error {
+	f := inheritedFile(inheritedReady)
+	if f == nil {
+		return nil
+	}
+	defer f.Close()
+
+	_, err := f.Write([]byte{1})
+	return err
+}
+
+// Released returns a channel, which is closed once the parent process on hot
+// restart released the inherited sockets, or immediately if not hot restarted.
+func Released() <-chan struct{} {
+	releaseOnce.Do(func() {
+		released = make(chan struct{})
+
+		f := inheritedFile(inheritedRelease)
+		if f == nil {
+			close(released)
+			return
+		}
+
+		go func() {
+			defer close(released)
+			defer f.Close()
+
+			// EOF once the parent process closed the pipe or exited.
+			io.Copy(io.Discard, f)
+		}()
+	})
+
+	return released
+}
+
+// Handoff is the new process which took over the listening sockets.
+type Handoff struct {
+	Process *os.Process
+	release *os.File
+}
+
+// Release tells the new process that the sockets are no longer read by this
+// process, which is also implied once this process exits.
+func (h *Handoff) Release() error {
+	return h.release.Close()
+}
+
+// StartProcess starts a new process of the binary with the same arguments, which
+// inherits all the listening sockets, and waits until it's ready to serve.
+func StartProcess(timeout time.Duration) (*Handoff, error) {
+	inheritOnce.Do(loadInheritedFiles)
+
+	// The binary may have been replaced, which is resolved by path instead of the
+	// running executable.
+	path, err := exec.LookPath(os.Args[0])
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to look up binary")
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer readyR.Close()
+
+	releaseR, releaseW, err := os.Pipe()
+	if err != nil {
+		readyW.Close()
+		return nil, err
+	}
+
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	var entries []string
+	inherit := func(name string, f *os.File) {
+		fd := inheritedFdOffset + len(cmd.ExtraFiles)
+		entries = append(entries, fmt.Sprintf("%s=%d", name, fd))
+		cmd.ExtraFiles = append(cmd.ExtraFiles, f)
+	}
+
+	socketsMu.Lock()
+	for name, s := range sockets {
+		// Closed sockets are not handed over.
+		if f, err := s.File(); err == nil {
+			defer f.Close()
+			inherit(name, f)
+		}
+	}
+	socketsMu.Unlock()
+
+	inherit(inheritedReady, readyW)
+	inherit(inheritedRelease, releaseR)
+	cmd.Env = append(os.Environ(), envInheritedFiles+"="+strings.Join(entries, ","))
+
+	err = cmd.Start()
+
+	// Only the new process holds the other ends of the pipes, so that closing
+	// is observed if either process exits.
+	readyW.Close()
+	releaseR.Close()
+
+	if err != nil {
+		releaseW.Close()
+		return nil, errors.WithMessage(err, "failed to start new process")
+	}
+
+	readyR.SetReadDeadline(time.Now().Add(timeout))
+	if _, err := readyR.Read(make([]byte, 1)); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		releaseW.Close()
+		return nil, errors.WithMessage(err, "new process not ready")
+	}
+
+	// Reap the new process in case it exits before this process.
+	go cmd.Wait()
+
+	return &Handoff{Process: cmd.Process, release: releaseW}, nil
+}
@@ -0,0 +1,108 @@
+package util
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	envTestRestarted = "UTIL_TEST_RESTARTED"
+
+	testRestartAddr = "127.0.0.1:0"
+)
+
+// runAsNewProcess runs the test binary as the new process on hot restart, which
+// only runs `TestRestartedProcess` in the specified mode.
+func runAsNewProcess(t *testing.T, mode string) {
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=^TestRestartedProcess$"}
+	t.Cleanup(func() { os.Args = args })
+
+	t.Setenv(envTestRestarted, mode)
+}
+
+func TestStartProcess(t *testing.T) {
+	l, err := ListenTCP(testRestartAddr)
+	require.NoError(t, err)
+
+	conn, inherited, err := ListenUDP(testRestartAddr)
+	require.NoError(t, err)
+	assert.False(t, inherited)
+
+	runAsNewProcess(t, "echo")
+
+	handoff, err := StartProcess(10 * time.Second)
+	require.NoError(t, err)
+
+	// Stop accepting, while the new process accepts from the same socket.
+	l.Close()
+
+	// Datagram is queued in the shared socket until released.
+	sender, err := net.Dial("udp", conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer sender.Close()
+
+	_, err = sender.Write([]byte("hello"))
+	require.NoError(t, err)
+
+	conn.Close()
+	require.NoError(t, handoff.Release())
+
+	c, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer c.Close()
+
+	c.SetReadDeadline(time.Now().Add(10 * time.Second))
+	data, err := io.ReadAll(c)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestStartProcessNotReady(t *testing.T) {
+	// New process exits without notifying ready.
+	runAsNewProcess(t, "not-ready")
+
+	_, err := StartProcess(10 * time.Second)
+	assert.Error(t, err)
+}
+
+// TestRestartedProcess runs as the new process, which echoes the datagram
+// inherited from the parent process to the inherited TCP listener.
+func TestRestartedProcess(t *testing.T) {
+	switch os.Getenv(envTestRestarted) {
+	case "":
+		t.Skip("only run as the new process on hot restart")
+	case "not-ready":
+		return
+	}
+
+	l, err := ListenTCP(testRestartAddr)
+	require.NoError(t, err)
+	defer l.Close()
+
+	conn, inherited, err := ListenUDP(testRestartAddr)
+	require.NoError(t, err)
+	require.True(t, inherited)
+	defer conn.Close()
+
+	require.NoError(t, NotifyReady())
+
+	c, err := l.Accept()
+	require.NoError(t, err)
+	defer c.Close()
+
+	<-Released()
+
+	buf := make([]byte, 64)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	_, err = c.Write(buf[:n])
+	require.NoError(t, err)
+}
@@ -0,0 +1,137 @@
+package util
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	DefaultNumShards = 64
+
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+)
+
+type mapShard[V comparable] struct {
+	mu    sync.RWMutex
+	items map[string]V
+}
+
+// ShardedMap is a lock-striped map keyed by string, which spreads the keys
+// into shards guarded by separate locks to reduce lock contention.
+type ShardedMap[V comparable] struct {
+	shards []*mapShard[V]
+	count  atomic.Int64
+}
+
+func NewShardedMap[V comparable](numShards int) *ShardedMap[V] {
+	if numShards <= 0 {
+		numShards = DefaultNumShards
+	}
+
+	m := &ShardedMap[V]{shards: make([]*mapShard[V], numShards)}
+	for i := range m.shards {
+		m.shards[i] = &mapShard[V]{items: make(map[string]V)}
+	}
+
+	return m
+}
+
+func (m *ShardedMap[V]) shard(key string) *mapShard[V] {
+	return m.shards[ShardIndex(key, len(m.shards))]
+}
+
+// Get returns the value stored for the key.
+func (m *ShardedMap[V]) Get(key string) (v V, ok bool) {
+	s := m.shard(key)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok = s.items[key]
+	return v, ok
+}
+
+// Swap stores the value for the key, and returns the previous value if any.
+func (m *ShardedMap[V]) Swap(key string, v V) (prev V, loaded bool) {
+	s := m.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, loaded = s.items[key]
+	s.items[key] = v
+	if !loaded {
+		m.count.Add(1)
+	}
+
+	return prev, loaded
+}
+
+// Set stores the value for the key.
+func (m *ShardedMap[V]) Set(key string, v V) {
+	m.Swap(key, v)
+}
+
+// Delete deletes the value for the key, and returns the deleted value if any.
+func (m *ShardedMap[V]) Delete(key string) (v V, ok bool) {
+	s := m.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok = s.items[key]
+	if ok {
+		delete(s.items, key)
+		m.count.Add(-1)
+	}
+
+	return v, ok
+}
+
+// CompareAndDelete deletes the value for the key only if it equals to the old value.
+func (m *ShardedMap[V]) CompareAndDelete(key string, old V) bool {
+	s := m.shard(key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v, ok := s.items[key]; !ok || v != old {
+		return false
+	}
+
+	delete(s.items, key)
+	m.count.Add(-1)
+	return true
+}
+
+// Len returns the number of stored keys in constant time.
+func (m *ShardedMap[V]) Len() int {
+	return int(m.count.Load())
+}
+
+// Values returns a snapshot of all the stored values.
+func (m *ShardedMap[V]) Values() []V {
+	res := make([]V, 0, m.Len())
+	for _, s := range m.shards {
+		s.mu.RLock()
+		for _, v := range s.items {
+			res = append(res, v)
+		}
+		s.mu.RUnlock()
+	}
+
+	return res
+}
+
+// ShardIndex hashes the key into the shard index with FNV-1a, which is inlined
+// to avoid allocation.
+func ShardIndex(key string, numShards int) int {
+	h := uint32(fnvOffset32)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= fnvPrime32
+	}
+
+	return int(h % uint32(numShards))
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	benchNumKeys = 100_000
+)
+
+func TestShardedMap(t *testing.T) {
+	m := NewShardedMap[*int](8)
+	one, two := new(int), new(int)
+
+	_, loaded := m.Swap("a", one)
+	assert.False(t, loaded)
+	prev, loaded := m.Swap("a", two)
+	assert.True(t, loaded)
+	assert.Equal(t, one, prev)
+	assert.Equal(t, 1, m.Len())
+
+	assert.False(t, m.CompareAndDelete("a", one))
+	assert.True(t, m.CompareAndDelete("a", two))
+	assert.Equal(t, 0, m.Len())
+
+	_, ok := m.Get("a")
+	assert.False(t, ok)
+}
+
+// mutexMap is a map guarded by a single mutex as the contention baseline.
+type mutexMap struct {
+	mu    sync.Mutex
+	items map[string]int
+}
+
+func (m *mutexMap) get(key string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.items[key]
+}
+
+func (m *mutexMap) set(key string, v int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items[key] = v
+}
+
+func benchKeys() []string {
+	keys := make([]string, benchNumKeys)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	return keys
+}
+
+func BenchmarkMutexMapParallel(b *testing.B) {
+	keys := benchKeys()
+	m := &mutexMap{items: make(map[string]int)}
+
+	var idx atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			i := int(idx.Add(1))
+			key := keys[i%len(keys)]
+
+			// Mix of 1 write per 4 reads
+			if i%5 == 0 {
+				m.set(key, i)
+			} else {
+				m.get(key)
+			}
+		}
+	})
+}
+
+func BenchmarkShardedMapParallel(b *testing.B) {
+	keys := benchKeys()
+	m := NewShardedMap[int](DefaultNumShards)
+
+	var idx atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			i := int(idx.Add(1))
+			key := keys[i%len(keys)]
+
+			// Mix of 1 write per 4 reads
+			if i%5 == 0 {
+				m.Set(key, i)
+			} else {
+				m.Get(key)
+			}
+		}
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package util
+
+import "os"
+
+// restartSignal is nil, since hot restart is not supported.
+var restartSignal os.Signal
@@ -0,0 +1,11 @@
+//go:build unix
+
+package util
+
+import (
+	"os"
+	"syscall"
+)
+
+// restartSignal triggers hot restart.
+var restartSignal os.Signal = syscall.SIGUSR2
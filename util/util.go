@@ -21,3 +21,24 @@ func GracefulShutdown(wg *sync.WaitGroup, shutdown func()) {
 
 	wg.Wait()
 }
+
+// GracefulRestart is like `GracefulShutdown`, but also hot restarts once SIGUSR2
+// captured, after which it shuts down if the new process took over, or keeps
+// running otherwise.
+func GracefulRestart(wg *sync.WaitGroup, restart func() bool, shutdown func()) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	if restartSignal != nil {
+		signal.Notify(sigChan, restartSignal)
+	}
+
+	for sig := range sigChan {
+		if sig != restartSignal || restart() {
+			break
+		}
+	}
+
+	shutdown()
+
+	wg.Wait()
+}